@@ -3,6 +3,7 @@ package radix
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"fmt"
@@ -106,6 +107,26 @@ func cmdString(m resp.Marshaler) string {
 	return "[" + strings.Join(ss, " ") + "]"
 }
 
+// unmarshalRESP unmarshals a command's reply into rcv, routing a RESP3 map
+// reply (as HELLO-negotiated connections may send for e.g. a hash read) into
+// resp.Map so it can be unmarshaled directly into a map[K]V or struct,
+// rather than being flattened into the alternating-key-value array rcv would
+// otherwise have to accept. A rcv which is itself a resp.Unmarshaler is
+// always handed the reply directly, whatever its type.
+func unmarshalRESP(br *bufio.Reader, rcv interface{}) error {
+	if u, ok := rcv.(resp.Unmarshaler); ok {
+		return u.UnmarshalRESP(br)
+	}
+	isMap, err := resp.IsMapHeader(br)
+	if err != nil {
+		return err
+	}
+	if isMap {
+		return resp.Map{I: rcv}.UnmarshalRESP(br)
+	}
+	return resp.Any{I: rcv}.UnmarshalRESP(br)
+}
+
 func marshalBulkString(prevErr error, w io.Writer, str string) error {
 	if prevErr != nil {
 		return prevErr
@@ -172,10 +193,18 @@ func (c *cmdAction) MarshalRESP(w io.Writer) error {
 }
 
 func (c *cmdAction) UnmarshalRESP(br *bufio.Reader) error {
-	return resp.Any{I: c.rcv}.UnmarshalRESP(br)
+	return unmarshalRESP(br, c.rcv)
 }
 
 func (c *cmdAction) Run(conn Conn) error {
+	if hc, ok := conn.(hookableConn); ok {
+		return hc.hookChain().processHooked(context.Background(), c, func(_ context.Context, cmd CmdAction) error {
+			if err := conn.Encode(cmd); err != nil {
+				return err
+			}
+			return conn.Decode(cmd)
+		})
+	}
 	if err := conn.Encode(c); err != nil {
 		return err
 	}
@@ -252,10 +281,18 @@ func (c *flatCmdAction) MarshalRESP(w io.Writer) error {
 }
 
 func (c *flatCmdAction) UnmarshalRESP(br *bufio.Reader) error {
-	return resp.Any{I: c.rcv}.UnmarshalRESP(br)
+	return unmarshalRESP(br, c.rcv)
 }
 
 func (c *flatCmdAction) Run(conn Conn) error {
+	if hc, ok := conn.(hookableConn); ok {
+		return hc.hookChain().processHooked(context.Background(), c, func(_ context.Context, cmd CmdAction) error {
+			if err := conn.Encode(cmd); err != nil {
+				return err
+			}
+			return conn.Decode(cmd)
+		})
+	}
 	if err := conn.Encode(c); err != nil {
 		return err
 	}
@@ -407,17 +444,23 @@ func (p pipeline) Keys() []string {
 }
 
 func (p pipeline) Run(c Conn) error {
-	for _, cmd := range p {
-		if err := c.Encode(cmd); err != nil {
-			return err
+	run := func(_ context.Context, cmds []CmdAction) error {
+		for _, cmd := range cmds {
+			if err := c.Encode(cmd); err != nil {
+				return err
+			}
 		}
-	}
-	for _, cmd := range p {
-		if err := c.Decode(cmd); err != nil {
-			return err
+		for _, cmd := range cmds {
+			if err := c.Decode(cmd); err != nil {
+				return err
+			}
 		}
+		return nil
+	}
+	if hc, ok := c.(hookableConn); ok {
+		return hc.hookChain().processPipelineHooked(context.Background(), []CmdAction(p), run)
 	}
-	return nil
+	return run(context.Background(), []CmdAction(p))
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -454,6 +497,11 @@ func (wc *withConn) Keys() []string {
 	return []string{wc.key}
 }
 
+// Run does not wrap fn in the Client's hook chain itself, since fn isn't a
+// CmdAction/pipeline the Hook interface can describe; it passes c straight
+// through, so any CmdAction fn runs against c is still individually wrapped
+// by cmdAction/flatCmdAction/pipeline's own Run, exactly as if it had been
+// run directly against the Client.
 func (wc *withConn) Run(c Conn) error {
 	return wc.fn(c)
 }
@@ -0,0 +1,204 @@
+package radix
+
+import (
+	"context"
+	"time"
+
+	"github.com/mediocregopher/radix.v3/resp"
+)
+
+// ActionCtx is an optional extension of the Action interface which Actions
+// can implement to support context.Context-aware execution. Actions which
+// don't implement ActionCtx can still be used anywhere a context is
+// accepted; their Run method is simply called as-is, ignoring the context.
+type ActionCtx interface {
+	Action
+
+	// RunCtx behaves like Run, but the Action should abort and return
+	// ctx.Err() as soon as possible if ctx is canceled or its deadline is
+	// exceeded.
+	RunCtx(ctx context.Context, c Conn) error
+}
+
+// CmdCtx is like Cmd, but the returned CmdAction's Run/RunCtx honor ctx: when
+// run against a Conn whose EncodeCtx/DecodeCtx apply deadlines to the
+// underlying connection (as the one returned by Dial does), the command is
+// aborted as soon as ctx is canceled or its deadline passes.
+func CmdCtx(ctx context.Context, rcv interface{}, cmd string, args ...string) CmdAction {
+	return &ctxCmdAction{ctx: ctx, cmdAction: cmdAction{rcv: rcv, cmd: cmd, args: args}}
+}
+
+type ctxCmdAction struct {
+	cmdAction
+	ctx context.Context
+}
+
+func (c *ctxCmdAction) Run(conn Conn) error { return c.RunCtx(c.ctx, conn) }
+
+func (c *ctxCmdAction) RunCtx(ctx context.Context, conn Conn) error {
+	run := func(ctx context.Context, _ CmdAction) error {
+		if err := conn.EncodeCtx(ctx, &c.cmdAction); err != nil {
+			return err
+		}
+		return conn.DecodeCtx(ctx, &c.cmdAction)
+	}
+	if hc, ok := conn.(hookableConn); ok {
+		return hc.hookChain().processHooked(ctx, c, run)
+	}
+	return run(ctx, c)
+}
+
+// FlatCmdCtx is like FlatCmd, but the returned CmdAction's Run/RunCtx honor
+// ctx the same way CmdCtx's does.
+func FlatCmdCtx(ctx context.Context, rcv interface{}, cmd, key string, args ...interface{}) CmdAction {
+	return &ctxFlatCmdAction{ctx: ctx, flatCmdAction: flatCmdAction{rcv: rcv, cmd: cmd, key: key, args: args}}
+}
+
+type ctxFlatCmdAction struct {
+	flatCmdAction
+	ctx context.Context
+}
+
+func (c *ctxFlatCmdAction) Run(conn Conn) error { return c.RunCtx(c.ctx, conn) }
+
+func (c *ctxFlatCmdAction) RunCtx(ctx context.Context, conn Conn) error {
+	run := func(ctx context.Context, _ CmdAction) error {
+		if err := conn.EncodeCtx(ctx, &c.flatCmdAction); err != nil {
+			return err
+		}
+		return conn.DecodeCtx(ctx, &c.flatCmdAction)
+	}
+	if hc, ok := conn.(hookableConn); ok {
+		return hc.hookChain().processHooked(ctx, c, run)
+	}
+	return run(ctx, c)
+}
+
+// PipelineCtx is like Pipeline, but the returned Action's Run/RunCtx honor
+// ctx: it's passed down to every command's EncodeCtx/DecodeCtx, so a Conn
+// which applies deadlines can abort the whole pipeline at the next I/O call
+// once ctx is canceled, rather than running it to completion.
+func PipelineCtx(ctx context.Context, cmds ...CmdAction) ActionCtx {
+	return &ctxPipeline{ctx: ctx, pipeline: pipeline(cmds)}
+}
+
+type ctxPipeline struct {
+	pipeline
+	ctx context.Context
+}
+
+func (p *ctxPipeline) Run(c Conn) error { return p.RunCtx(p.ctx, c) }
+
+func (p *ctxPipeline) RunCtx(ctx context.Context, c Conn) error {
+	run := func(ctx context.Context, cmds []CmdAction) error {
+		for _, cmd := range cmds {
+			if err := c.EncodeCtx(ctx, cmd); err != nil {
+				return err
+			}
+		}
+		for _, cmd := range cmds {
+			if err := c.DecodeCtx(ctx, cmd); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if hc, ok := c.(hookableConn); ok {
+		return hc.hookChain().processPipelineHooked(ctx, []CmdAction(p.pipeline), run)
+	}
+	return run(ctx, []CmdAction(p.pipeline))
+}
+
+// WithConnCtx is like WithConn, but fn is passed ctx directly so it can be
+// threaded down into any CmdCtx/FlatCmdCtx/PipelineCtx calls made within it.
+func WithConnCtx(ctx context.Context, key string, fn func(context.Context, Conn) error) ActionCtx {
+	return &ctxWithConn{ctx: ctx, key: key, fn: fn}
+}
+
+type ctxWithConn struct {
+	key string
+	fn  func(context.Context, Conn) error
+	ctx context.Context
+}
+
+func (wc *ctxWithConn) Keys() []string { return []string{wc.key} }
+
+func (wc *ctxWithConn) Run(c Conn) error { return wc.RunCtx(wc.ctx, c) }
+
+func (wc *ctxWithConn) RunCtx(ctx context.Context, c Conn) error { return wc.fn(ctx, c) }
+
+////////////////////////////////////////////////////////////////////////////////
+
+// deadlineConn is implemented by the net.Conn-backed Conn a Dial/connPool
+// hands out, letting EncodeCtx/DecodeCtx translate ctx into the read/write
+// deadlines the stdlib actually enforces. A Conn which doesn't implement this
+// runs Encode/Decode as normal, ignoring ctx.
+type deadlineConn interface {
+	SetWriteDeadline(t time.Time) error
+	SetReadDeadline(t time.Time) error
+}
+
+// EncodeCtx implements the Conn interface for connWrap: it honors ctx by
+// setting conn's write deadline (if ctx has one) before delegating to
+// Encode, and additionally aborts the in-flight write as soon as ctx is
+// canceled even if ctx has no deadline of its own.
+func (conn *connWrap) EncodeCtx(ctx context.Context, m resp.Marshaler) error {
+	return conn.withDeadlineCtx(ctx, true, func() error {
+		return conn.Conn.Encode(m)
+	})
+}
+
+// DecodeCtx is the read analog of EncodeCtx.
+func (conn *connWrap) DecodeCtx(ctx context.Context, u resp.Unmarshaler) error {
+	return conn.withDeadlineCtx(ctx, false, func() error {
+		return conn.Decode(u)
+	})
+}
+
+// withDeadlineCtx runs fn against conn's underlying connection, honoring
+// ctx's deadline (if any) and aborting fn as soon as ctx is done even
+// without one, by forcing an immediate read/write deadline on cancellation.
+// If the wrapped Conn doesn't support deadlines at all, ctx is ignored and
+// fn is run as-is, same as before EncodeCtx/DecodeCtx existed.
+func (conn *connWrap) withDeadlineCtx(ctx context.Context, write bool, fn func() error) error {
+	dc, ok := conn.Conn.(deadlineConn)
+	if !ok || ctx.Done() == nil {
+		return fn()
+	}
+
+	setDeadline := func(t time.Time) error {
+		if write {
+			return dc.SetWriteDeadline(t)
+		}
+		return dc.SetReadDeadline(t)
+	}
+
+	// Always clear the deadline on exit, not just when ctx.Deadline() was
+	// set: the cancellation watcher below can force one even without it,
+	// and leaving that in place would permanently fail every subsequent
+	// read/write on a reused/pooled connection.
+	defer setDeadline(time.Time{})
+
+	if dl, hasDeadline := ctx.Deadline(); hasDeadline {
+		if err := setDeadline(dl); err != nil {
+			return err
+		}
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// force the blocked read/write to return immediately
+			setDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
+	err := fn()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,96 @@
+package radix
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mediocregopher/radix.v3/resp"
+)
+
+// fakeDeadlineConn is a minimal Conn which also implements deadlineConn, for
+// exercising connWrap's EncodeCtx/DecodeCtx without a real network conn.
+type fakeDeadlineConn struct {
+	encode func() error
+	decode func() error
+
+	readDeadlines, writeDeadlines []time.Time
+}
+
+func (f *fakeDeadlineConn) Encode(resp.Marshaler) error   { return f.encode() }
+func (f *fakeDeadlineConn) Decode(resp.Unmarshaler) error { return f.decode() }
+func (f *fakeDeadlineConn) Close() error                  { return nil }
+
+func (f *fakeDeadlineConn) SetReadDeadline(t time.Time) error {
+	f.readDeadlines = append(f.readDeadlines, t)
+	return nil
+}
+
+func (f *fakeDeadlineConn) SetWriteDeadline(t time.Time) error {
+	f.writeDeadlines = append(f.writeDeadlines, t)
+	return nil
+}
+
+func TestConnWrapDecodeCtxAppliesDeadline(t *testing.T) {
+	f := &fakeDeadlineConn{decode: func() error { return nil }}
+	cw := &connWrap{Conn: f}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := cw.DecodeCtx(ctx, resp.Any{}); err != nil {
+		t.Fatalf("DecodeCtx: %v", err)
+	}
+	if len(f.readDeadlines) != 2 {
+		t.Fatalf("expected a deadline to be set then cleared, got %v", f.readDeadlines)
+	}
+	if f.readDeadlines[1] != (time.Time{}) {
+		t.Errorf("expected the deadline to be cleared after Decode, got %v", f.readDeadlines[1])
+	}
+}
+
+// TestConnWrapDecodeCtxClearsDeadlineOnPlainCancellation is a regression test
+// for a bug where withDeadlineCtx only cleared the deadline it had set when
+// ctx.Deadline() returned one. A plain context.WithCancel ctx (no deadline)
+// canceled mid-call would leave the connection's read deadline forced to
+// time.Now() forever, since nothing ever reset it.
+func TestConnWrapDecodeCtxClearsDeadlineOnPlainCancellation(t *testing.T) {
+	started := make(chan struct{})
+	f := &fakeDeadlineConn{decode: func() error {
+		close(started)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}}
+	cw := &connWrap{Conn: f}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	if err := cw.DecodeCtx(ctx, resp.Any{}); err != nil && !errors.Is(err, context.Canceled) {
+		t.Fatalf("DecodeCtx: %v", err)
+	}
+
+	if len(f.readDeadlines) == 0 {
+		t.Fatal("expected a deadline to have been forced by cancellation")
+	}
+	if last := f.readDeadlines[len(f.readDeadlines)-1]; last != (time.Time{}) {
+		t.Errorf("expected the forced deadline to be cleared on exit, got %v", last)
+	}
+}
+
+func TestConnWrapEncodeCtxAbortsOnCancel(t *testing.T) {
+	f := &fakeDeadlineConn{encode: func() error { return nil }}
+	cw := &connWrap{Conn: f}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // already canceled before EncodeCtx is even called
+
+	err := cw.EncodeCtx(ctx, resp.Any{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("EncodeCtx returned %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,162 @@
+package radix
+
+import "context"
+
+// Hook is implemented by types which want to wrap every command (or
+// pipeline) a Client runs with cross-cutting logic, e.g. tracing spans,
+// metrics, slow-log capture, circuit breaking, or retries. A Hook is applied
+// to a Client with WithHooks; every CmdAction and pipeline run through the
+// resulting Client's Do has the applicable methods called around it.
+//
+// A Hook need not implement every method; embed HookBase to get no-op
+// defaults for the ones it doesn't care about.
+type Hook interface {
+	// BeforeProcess is called immediately before a single CmdAction is run.
+	// The returned context (and error) take the place of ctx/err for the
+	// remainder of the chain, mirroring the shape used by go-redis.
+	BeforeProcess(ctx context.Context, cmd CmdAction) (context.Context, error)
+
+	// AfterProcess is called immediately after a single CmdAction finishes,
+	// with the error (if any) it returned.
+	AfterProcess(ctx context.Context, cmd CmdAction, err error) error
+
+	// BeforeProcessPipeline is called immediately before a pipeline of
+	// commands is run.
+	BeforeProcessPipeline(ctx context.Context, cmds []CmdAction) (context.Context, error)
+
+	// AfterProcessPipeline is called immediately after a pipeline of
+	// commands finishes, with the error (if any) it returned.
+	AfterProcessPipeline(ctx context.Context, cmds []CmdAction, err error) error
+}
+
+// HookBase can be embedded into a Hook implementation to satisfy the
+// interface with no-op defaults, so that type only needs to implement the
+// methods it actually cares about.
+type HookBase struct{}
+
+// BeforeProcess implements the Hook interface by doing nothing.
+func (HookBase) BeforeProcess(ctx context.Context, cmd CmdAction) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterProcess implements the Hook interface by doing nothing.
+func (HookBase) AfterProcess(ctx context.Context, cmd CmdAction, err error) error {
+	return err
+}
+
+// BeforeProcessPipeline implements the Hook interface by doing nothing.
+func (HookBase) BeforeProcessPipeline(ctx context.Context, cmds []CmdAction) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterProcessPipeline implements the Hook interface by doing nothing.
+func (HookBase) AfterProcessPipeline(ctx context.Context, cmds []CmdAction, err error) error {
+	return err
+}
+
+// hooks is an ordered, immutable chain of Hooks.
+type hooks []Hook
+
+// processHooked runs cmd through every registered hook's Before/AfterProcess
+// and then run in between, stopping early and skipping run (and later
+// Before hooks) if any BeforeProcess returns an error.
+func (hs hooks) processHooked(ctx context.Context, cmd CmdAction, run func(context.Context, CmdAction) error) error {
+	var err error
+	i := 0
+	for ; i < len(hs); i++ {
+		if ctx, err = hs[i].BeforeProcess(ctx, cmd); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = run(ctx, cmd)
+	}
+	for i--; i >= 0; i-- {
+		err = hs[i].AfterProcess(ctx, cmd, err)
+	}
+	return err
+}
+
+// processPipelineHooked is the pipeline analog of processHooked.
+func (hs hooks) processPipelineHooked(ctx context.Context, cmds []CmdAction, run func(context.Context, []CmdAction) error) error {
+	var err error
+	i := 0
+	for ; i < len(hs); i++ {
+		if ctx, err = hs[i].BeforeProcessPipeline(ctx, cmds); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = run(ctx, cmds)
+	}
+	for i--; i >= 0; i-- {
+		err = hs[i].AfterProcessPipeline(ctx, cmds, err)
+	}
+	return err
+}
+
+// hookableConn is implemented by a Conn which carries a Client's hook chain
+// alongside it, as the Conn a Client.Do hands to an Action's Run does.
+// cmdAction/flatCmdAction/pipeline check for this via a type assertion so
+// hooks fire around their Encode/Decode calls without Action needing to know
+// about Client at all; a bare Conn used directly (e.g. via Dial) simply
+// doesn't implement this and runs unhooked, same as before hooks existed.
+type hookableConn interface {
+	Conn
+	hookChain() hooks
+}
+
+// WithHooks wraps c so that every Action run through the returned Client's
+// Do has hs applied around it. Hooks run in the order given, with
+// BeforeProcess(Pipeline) called outermost-first and AfterProcess(Pipeline)
+// called outermost-last, so the first hook given sees the full duration of
+// everything after it.
+//
+// Client is implemented by multiple, independent types (Pool, Cluster,
+// ShardedPool, ...), so hooks can't be stored as a field on it; WithHooks
+// instead returns a decorator that injects hs into the Conn it hands down to
+// each Action's Run, which cmdAction/flatCmdAction/pipeline already know how
+// to pick up via the hookableConn mechanism.
+func WithHooks(c Client, hs ...Hook) Client {
+	return &hookedClient{Client: c, hooks: hs}
+}
+
+type hookedClient struct {
+	Client
+	hooks hooks
+}
+
+// Do implements the Client interface by wrapping a so that the Conn it runs
+// against carries hc's hook chain, then delegating to the wrapped Client.
+func (hc *hookedClient) Do(a Action) error {
+	return hc.Client.Do(&hookedAction{Action: a, hooks: hc.hooks})
+}
+
+// hookedAction wraps an Action so that whatever Conn it's ultimately Run
+// against is augmented with a hook chain, making it a hookableConn.
+type hookedAction struct {
+	Action
+	hooks hooks
+}
+
+func (ha *hookedAction) Run(c Conn) error {
+	return ha.Action.Run(&hookedConn{Conn: c, hooks: ha.hooks})
+}
+
+func (ha *hookedAction) RunCtx(ctx context.Context, c Conn) error {
+	hc := &hookedConn{Conn: c, hooks: ha.hooks}
+	if ac, ok := ha.Action.(ActionCtx); ok {
+		return ac.RunCtx(ctx, hc)
+	}
+	return ha.Action.Run(hc)
+}
+
+// hookedConn implements hookableConn by pairing a Conn with a fixed hook
+// chain, so cmdAction/flatCmdAction/pipeline fire hs around their
+// Encode/Decode calls without needing to know about Client at all.
+type hookedConn struct {
+	Conn
+	hooks hooks
+}
+
+func (hc *hookedConn) hookChain() hooks { return hc.hooks }
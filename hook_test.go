@@ -0,0 +1,116 @@
+package radix
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mediocregopher/radix.v3/resp"
+)
+
+// recordingConn is a Conn which also carries a hook chain, so cmdAction's
+// Run/pipeline's Run pick it up as a hookableConn. It just logs what's
+// encoded/decoded rather than talking to anything real.
+type recordingConn struct {
+	hc    hooks
+	calls *[]string
+}
+
+func (r recordingConn) Encode(m resp.Marshaler) error {
+	*r.calls = append(*r.calls, "encode")
+	return nil
+}
+
+func (r recordingConn) Decode(u resp.Unmarshaler) error {
+	*r.calls = append(*r.calls, "decode")
+	return nil
+}
+
+func (r recordingConn) Close() error { return nil }
+
+func (r recordingConn) hookChain() hooks { return r.hc }
+
+// orderHook records which of its methods fired, in order, as a single
+// shared log so ordering can be asserted across Before/AfterProcess.
+type orderHook struct {
+	HookBase
+	name string
+	log  *[]string
+}
+
+func (h orderHook) BeforeProcess(ctx context.Context, cmd CmdAction) (context.Context, error) {
+	*h.log = append(*h.log, h.name+":before")
+	return ctx, nil
+}
+
+func (h orderHook) AfterProcess(ctx context.Context, cmd CmdAction, err error) error {
+	*h.log = append(*h.log, h.name+":after")
+	return err
+}
+
+func (h orderHook) BeforeProcessPipeline(ctx context.Context, cmds []CmdAction) (context.Context, error) {
+	*h.log = append(*h.log, h.name+":before")
+	return ctx, nil
+}
+
+func (h orderHook) AfterProcessPipeline(ctx context.Context, cmds []CmdAction, err error) error {
+	*h.log = append(*h.log, h.name+":after")
+	return err
+}
+
+func TestCmdActionRunFiresHooksOutermostFirst(t *testing.T) {
+	var log []string
+	hs := hooks{orderHook{name: "outer", log: &log}, orderHook{name: "inner", log: &log}}
+
+	var calls []string
+	conn := recordingConn{hc: hs, calls: &calls}
+
+	if err := Cmd(nil, "PING").Run(conn); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := []string{"outer:before", "inner:before", "inner:after", "outer:after"}
+	if len(log) != len(want) {
+		t.Fatalf("hook log = %v, want %v", log, want)
+	}
+	for i := range want {
+		if log[i] != want[i] {
+			t.Fatalf("hook log = %v, want %v", log, want)
+		}
+	}
+	if len(calls) != 2 || calls[0] != "encode" || calls[1] != "decode" {
+		t.Fatalf("expected one encode then one decode, got %v", calls)
+	}
+}
+
+func TestCmdActionRunWithoutHooksStillWorks(t *testing.T) {
+	var calls []string
+	conn := recordingConn{calls: &calls}
+
+	if err := Cmd(nil, "PING").Run(conn); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected one encode then one decode, got %v", calls)
+	}
+}
+
+func TestPipelineRunFiresPipelineHooks(t *testing.T) {
+	var log []string
+	hs := hooks{orderHook{name: "only", log: &log}}
+
+	var calls []string
+	conn := recordingConn{hc: hs, calls: &calls}
+
+	p := Pipeline(Cmd(nil, "PING"), Cmd(nil, "PING"))
+	if err := p.Run(conn); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := []string{"only:before", "only:after"}
+	if len(log) != len(want) || log[0] != want[0] || log[1] != want[1] {
+		t.Fatalf("hook log = %v, want %v", log, want)
+	}
+	if len(calls) != 4 {
+		t.Fatalf("expected two encodes then two decodes, got %v", calls)
+	}
+}
@@ -0,0 +1,319 @@
+package radix
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/mediocregopher/radix.v3/resp"
+)
+
+// PubSubMessage describes a message being published to a subscribed channel.
+type PubSubMessage struct {
+	Type    string // "message" or "pmessage"
+	Pattern string // will be set if Type is "pmessage"
+	Channel string
+	Message []byte
+}
+
+// PubSubConn wraps an existing Conn which has been put into pub/sub mode,
+// managing SUBSCRIBE/PSUBSCRIBE/UNSUBSCRIBE state and publishing incoming
+// messages on a channel.
+//
+// A Conn which has had PubSubConn called on it can no longer have normal
+// commands run on it via Conn.Do; once subscribed, redis only accepts
+// (P)SUBSCRIBE, (P)UNSUBSCRIBE, and PING on that connection, so PubSubConn
+// exposes its own command surface for those instead.
+type PubSubConn interface {
+	// Subscribe subscribes to the given channels, in addition to any
+	// channels already subscribed to.
+	Subscribe(channels ...string) error
+
+	// PSubscribe is like Subscribe, but for patterns.
+	PSubscribe(patterns ...string) error
+
+	// Unsubscribe unsubscribes from the given channels. If no channels are
+	// given, all channel (not pattern) subscriptions are removed.
+	Unsubscribe(channels ...string) error
+
+	// PUnsubscribe is like Unsubscribe, but for patterns.
+	PUnsubscribe(patterns ...string) error
+
+	// Ping performs a PING on the connection, primarily useful for
+	// keepalive/health-checking a subscribed connection.
+	Ping() error
+
+	// Next blocks until a message is received and returns it. It returns an
+	// error if the underlying Conn errors out, at which point the
+	// PubSubConn should be considered dead.
+	Next() (PubSubMessage, error)
+
+	// Close closes the underlying Conn.
+	Close() error
+}
+
+// NewPubSubConn takes an existing, unused Conn and wraps it to support the
+// pub/sub command surface described by PubSubConn.
+func NewPubSubConn(conn Conn) PubSubConn {
+	return &pubSubConn{conn: conn}
+}
+
+type pubSubConn struct {
+	conn Conn
+}
+
+func (p *pubSubConn) subUnsub(cmd string, args []string) error {
+	if err := p.conn.Encode(Cmd(nil, cmd, args...)); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		for range args {
+			var resh []interface{}
+			if err := p.conn.Decode(resp.Any{I: &resh}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// UNSUBSCRIBE/PUNSUBSCRIBE given no channels/patterns means "all of
+	// them", so unlike the case above there's no way to know in advance how
+	// many confirmations are coming (it's the number of subscriptions that
+	// were actually dropped, which may be zero). Redis always sends at least
+	// one confirmation, whose trailing remaining-subscriptions count is 0 on
+	// the last one, so read until that shows up.
+	for {
+		var resh []interface{}
+		if err := p.conn.Decode(resp.Any{I: &resh}); err != nil {
+			return err
+		}
+		if len(resh) == 3 {
+			if n, ok := resh[2].(int64); ok && n == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+func (p *pubSubConn) Subscribe(channels ...string) error {
+	return p.subUnsub("SUBSCRIBE", channels)
+}
+
+func (p *pubSubConn) PSubscribe(patterns ...string) error {
+	return p.subUnsub("PSUBSCRIBE", patterns)
+}
+
+func (p *pubSubConn) Unsubscribe(channels ...string) error {
+	return p.subUnsub("UNSUBSCRIBE", channels)
+}
+
+func (p *pubSubConn) PUnsubscribe(patterns ...string) error {
+	return p.subUnsub("PUNSUBSCRIBE", patterns)
+}
+
+func (p *pubSubConn) Ping() error {
+	return Cmd(nil, "PING").Run(p.conn)
+}
+
+func (p *pubSubConn) Next() (PubSubMessage, error) {
+	var parts []string
+	if err := p.conn.Decode(resp.Any{I: &parts}); err != nil {
+		return PubSubMessage{}, err
+	}
+
+	var m PubSubMessage
+	switch {
+	case len(parts) == 3 && parts[0] == "message":
+		m = PubSubMessage{Type: "message", Channel: parts[1], Message: []byte(parts[2])}
+	case len(parts) == 4 && parts[0] == "pmessage":
+		m = PubSubMessage{Type: "pmessage", Pattern: parts[1], Channel: parts[2], Message: []byte(parts[3])}
+	default:
+		return PubSubMessage{}, errors.New("radix: unexpected pub/sub reply: " + strconv.Quote(parts[0]))
+	}
+	return m, nil
+}
+
+func (p *pubSubConn) Close() error {
+	return p.conn.Close()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// PersistentPubSub wraps a dial function to produce a PubSubConn which
+// transparently reconnects on any error: when the underlying connection
+// dies, a new one is dialed and every channel/pattern which was subscribed
+// to at the time of the failure is automatically re-subscribed before
+// message delivery resumes.
+//
+// Callers don't need to do anything special to benefit from this; Subscribe
+// et al. update the tracked subscription set as normal, and Next
+// transparently blocks through a reconnect instead of returning an error for
+// it. Next does return an error if dialing the new connection, or
+// re-subscribing on it, fails.
+func PersistentPubSub(dial func() (Conn, error)) PubSubConn {
+	p := &persistentPubSub{dial: dial}
+	return p
+}
+
+type persistentPubSub struct {
+	dial func() (Conn, error)
+
+	l        sync.Mutex
+	curr     PubSubConn
+	channels map[string]bool
+	patterns map[string]bool
+	closed   bool
+}
+
+func (p *persistentPubSub) ensureConn() (PubSubConn, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if p.curr != nil {
+		return p.curr, nil
+	}
+	if p.closed {
+		return nil, errors.New("radix: PersistentPubSub is closed")
+	}
+
+	conn, err := p.dial()
+	if err != nil {
+		return nil, err
+	}
+	psc := NewPubSubConn(conn)
+
+	if len(p.channels) > 0 {
+		channels := make([]string, 0, len(p.channels))
+		for c := range p.channels {
+			channels = append(channels, c)
+		}
+		if err := psc.Subscribe(channels...); err != nil {
+			psc.Close()
+			return nil, err
+		}
+	}
+	if len(p.patterns) > 0 {
+		patterns := make([]string, 0, len(p.patterns))
+		for pt := range p.patterns {
+			patterns = append(patterns, pt)
+		}
+		if err := psc.PSubscribe(patterns...); err != nil {
+			psc.Close()
+			return nil, err
+		}
+	}
+
+	p.curr = psc
+	return psc, nil
+}
+
+func (p *persistentPubSub) dropConn() {
+	p.l.Lock()
+	p.curr = nil
+	p.l.Unlock()
+}
+
+func (p *persistentPubSub) withConn(fn func(PubSubConn) error) error {
+	psc, err := p.ensureConn()
+	if err != nil {
+		return err
+	}
+	if err := fn(psc); err != nil {
+		p.dropConn()
+		return err
+	}
+	return nil
+}
+
+func (p *persistentPubSub) Subscribe(channels ...string) error {
+	err := p.withConn(func(psc PubSubConn) error { return psc.Subscribe(channels...) })
+	if err == nil {
+		p.l.Lock()
+		if p.channels == nil {
+			p.channels = map[string]bool{}
+		}
+		for _, c := range channels {
+			p.channels[c] = true
+		}
+		p.l.Unlock()
+	}
+	return err
+}
+
+func (p *persistentPubSub) PSubscribe(patterns ...string) error {
+	err := p.withConn(func(psc PubSubConn) error { return psc.PSubscribe(patterns...) })
+	if err == nil {
+		p.l.Lock()
+		if p.patterns == nil {
+			p.patterns = map[string]bool{}
+		}
+		for _, pt := range patterns {
+			p.patterns[pt] = true
+		}
+		p.l.Unlock()
+	}
+	return err
+}
+
+func (p *persistentPubSub) Unsubscribe(channels ...string) error {
+	err := p.withConn(func(psc PubSubConn) error { return psc.Unsubscribe(channels...) })
+	if err == nil {
+		p.l.Lock()
+		if len(channels) == 0 {
+			p.channels = nil
+		} else {
+			for _, c := range channels {
+				delete(p.channels, c)
+			}
+		}
+		p.l.Unlock()
+	}
+	return err
+}
+
+func (p *persistentPubSub) PUnsubscribe(patterns ...string) error {
+	err := p.withConn(func(psc PubSubConn) error { return psc.PUnsubscribe(patterns...) })
+	if err == nil {
+		p.l.Lock()
+		if len(patterns) == 0 {
+			p.patterns = nil
+		} else {
+			for _, pt := range patterns {
+				delete(p.patterns, pt)
+			}
+		}
+		p.l.Unlock()
+	}
+	return err
+}
+
+func (p *persistentPubSub) Ping() error {
+	return p.withConn(func(psc PubSubConn) error { return psc.Ping() })
+}
+
+func (p *persistentPubSub) Next() (PubSubMessage, error) {
+	for {
+		psc, err := p.ensureConn()
+		if err != nil {
+			return PubSubMessage{}, err
+		}
+		m, err := psc.Next()
+		if err == nil {
+			return m, nil
+		}
+		p.dropConn()
+	}
+}
+
+func (p *persistentPubSub) Close() error {
+	p.l.Lock()
+	defer p.l.Unlock()
+	p.closed = true
+	if p.curr == nil {
+		return nil
+	}
+	err := p.curr.Close()
+	p.curr = nil
+	return err
+}
@@ -0,0 +1,86 @@
+package radix
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/mediocregopher/radix.v3/resp"
+)
+
+// scriptedConn replays canned RESP replies from a buffer and discards
+// anything Encode'd to it, for driving pubSubConn without a real redis.
+type scriptedConn struct {
+	br *bufio.Reader
+}
+
+func newScriptedConn(replies string) *scriptedConn {
+	return &scriptedConn{br: bufio.NewReader(bytes.NewBufferString(replies))}
+}
+
+func (c *scriptedConn) Encode(resp.Marshaler) error { return nil }
+func (c *scriptedConn) Decode(u resp.Unmarshaler) error {
+	return u.UnmarshalRESP(c.br)
+}
+func (c *scriptedConn) Close() error { return nil }
+
+func TestPubSubConnUnsubscribeAllReadsUntilZeroCount(t *testing.T) {
+	// Two confirmations for an unsubscribe-all, as redis would send for a
+	// client subscribed to two channels: remaining counts 1, then 0.
+	replies := "*3\r\n$11\r\nunsubscribe\r\n$3\r\nfoo\r\n:1\r\n" +
+		"*3\r\n$11\r\nunsubscribe\r\n$3\r\nbar\r\n:0\r\n"
+	conn := newScriptedConn(replies)
+	psc := NewPubSubConn(conn).(*pubSubConn)
+
+	if err := psc.Unsubscribe(); err != nil {
+		t.Fatalf("Unsubscribe(): %v", err)
+	}
+}
+
+func TestPubSubConnUnsubscribeAllNoSubscriptions(t *testing.T) {
+	// redis still sends exactly one confirmation, with a nil channel and a
+	// remaining count of 0, even with nothing to unsubscribe from.
+	replies := "*3\r\n$11\r\nunsubscribe\r\n$-1\r\n:0\r\n"
+	conn := newScriptedConn(replies)
+	psc := NewPubSubConn(conn).(*pubSubConn)
+
+	if err := psc.Unsubscribe(); err != nil {
+		t.Fatalf("Unsubscribe(): %v", err)
+	}
+}
+
+func TestPersistentPubSubUnsubscribeOnlyUpdatesStateOnSuccess(t *testing.T) {
+	p := &persistentPubSub{
+		channels: map[string]bool{"foo": true},
+		curr:     failingPubSubConn{},
+	}
+
+	if err := p.Unsubscribe(); err == nil {
+		t.Fatal("expected Unsubscribe to return the underlying error")
+	}
+	if !p.channels["foo"] {
+		t.Fatal("a failed Unsubscribe must not drop channels from the tracked set")
+	}
+}
+
+// failingPubSubConn is a PubSubConn whose every method fails, for testing
+// persistentPubSub's handling of an unsubscribe that never reaches redis.
+type failingPubSubConn struct{}
+
+func (failingPubSubConn) Subscribe(...string) error   { return errTestFail }
+func (failingPubSubConn) PSubscribe(...string) error  { return errTestFail }
+func (failingPubSubConn) Unsubscribe(...string) error { return errTestFail }
+func (failingPubSubConn) PUnsubscribe(...string) error {
+	return errTestFail
+}
+func (failingPubSubConn) Ping() error { return errTestFail }
+func (failingPubSubConn) Next() (PubSubMessage, error) {
+	return PubSubMessage{}, errTestFail
+}
+func (failingPubSubConn) Close() error { return nil }
+
+type testFailError string
+
+func (e testFailError) Error() string { return string(e) }
+
+const errTestFail = testFailError("radix_test: intentional failure")
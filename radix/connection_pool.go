@@ -1,75 +1,330 @@
 package radix
 
 import (
+	"context"
+	"errors"
 	"sync"
+	"time"
 )
 
-// connPool is a stack-like structure that holds the connections of a Client.
+// ErrPoolTimeout is returned by connPool.pull when ctx's deadline passes
+// before a connection becomes available. If ctx is canceled instead, pull
+// returns ctx.Err().
+var ErrPoolTimeout = errors.New("radix: pool timeout, no connection available")
+
+// ErrPoolClosed is returned by connPool.pull once the pool has been closed.
+var ErrPoolClosed = errors.New("radix: pool is closed")
+
+// PoolConfig exposes the knobs for connPool's behavior beyond the original
+// fixed PoolSize. All fields are optional; a zero value either disables the
+// corresponding feature or selects the default noted on the field.
+type PoolConfig struct {
+	// MinIdleConns is the minimum number of idle connections to keep open.
+	// The reaper will not close an idle connection if doing so would drop
+	// the pool below this count.
+	MinIdleConns int
+
+	// MaxIdleConns is the maximum number of idle connections to keep open;
+	// a connection returned via push beyond this count is closed instead of
+	// being kept. 0 means PoolSize.
+	MaxIdleConns int
+
+	// MaxConnLifetime is the maximum amount of time a connection may be
+	// reused for. A connection older than this is closed on push rather
+	// than returned to the idle set. 0 means connections are never retired
+	// due to age.
+	MaxConnLifetime time.Duration
+
+	// IdleTimeout is the maximum amount of time a connection may sit idle in
+	// the pool before the reaper closes it. 0 disables idle reaping.
+	IdleTimeout time.Duration
+
+	// ReapInterval is how often the background reaper goroutine checks for
+	// connections past MaxConnLifetime/IdleTimeout. 0 means 1 minute.
+	ReapInterval time.Duration
+
+	// HealthCheckThreshold is how long a connection may sit unused before
+	// pull pings it to confirm it's still healthy; a connection which has
+	// seen an I/O error is always health-checked regardless of age. 0
+	// disables the age-based check only; connections which have seen an
+	// I/O error are still checked.
+	HealthCheckThreshold time.Duration
+}
+
+func (pc PoolConfig) withDefaults(poolSize int) PoolConfig {
+	if pc.MaxIdleConns == 0 {
+		pc.MaxIdleConns = poolSize
+	}
+	if pc.ReapInterval == 0 {
+		pc.ReapInterval = time.Minute
+	}
+	return pc
+}
+
+// PoolStats is a snapshot of a connPool's activity, returned by PoolStats.
+type PoolStats struct {
+	Hits       uint64 // pulls satisfied by an idle connection
+	Misses     uint64 // pulls which had to create a new connection
+	Timeouts   uint64 // pulls which gave up due to ctx/ErrPoolTimeout
+	TotalConns uint32 // connections currently outstanding or idle
+	IdleConns  uint32 // connections currently idle in the pool
+	StaleConns uint32 // idle connections closed so far by the reaper
+}
+
+// pooledConn wraps a *connection with the bookkeeping connPool needs to
+// enforce MaxConnLifetime/IdleTimeout/health-checking, without requiring any
+// changes to connection itself.
+type pooledConn struct {
+	conn       *connection
+	createdAt  time.Time
+	lastUsedAt time.Time
+	hadErr     bool
+}
+
+// connPool is a connPool-managed set of *connection, supporting bounded
+// idle/lifetime retention, context-bound acquisition, and background
+// reaping. It replaces the original fixed-size blocking stack.
 type connPool struct {
-	size          int
-	capacity      int
-	pool          []*connection
-	lock          *sync.Mutex
-	fullCond      *sync.Cond
-	emptyCond     *sync.Cond
 	configuration *Configuration
+	poolConfig    PoolConfig
+
+	l         sync.Mutex
+	idle      []*pooledConn
+	numOpen   int
+	waiters   []chan struct{}
+	closed    bool
+	closeOnce sync.Once
+	stopReap  chan struct{}
+
+	// createdAt tracks each open connection's original dial time across
+	// pull/push cycles, since pull hands back a bare *connection (discarding
+	// the pooledConn wrapper and whatever createdAt it had). Entries are
+	// removed once a connection is closed for good.
+	createdAt map[*connection]time.Time
+
+	stats PoolStats
 }
 
 func newConnPool(conf *Configuration) *connPool {
-	locker := &sync.Mutex{}
+	return newConnPoolWithConfig(conf, PoolConfig{})
+}
+
+// newConnPoolWithConfig is like newConnPool, but also accepts a PoolConfig
+// for the new knobs (idle bounds, lifetime/idle timeout, health checks).
+// Existing callers which only set Configuration.PoolSize keep working
+// exactly as before by going through newConnPool.
+func newConnPoolWithConfig(conf *Configuration, poolConfig PoolConfig) *connPool {
 	cp := &connPool{
-		size:          conf.PoolSize,
-		capacity:      conf.PoolSize,
-		pool:          make([]*connection, conf.PoolSize),
-		lock:          locker,
-		fullCond:      sync.NewCond(locker),
-		emptyCond:     sync.NewCond(locker),
 		configuration: conf,
+		poolConfig:    poolConfig.withDefaults(conf.PoolSize),
+		idle:          make([]*pooledConn, 0, conf.PoolSize),
+		stopReap:      make(chan struct{}),
+		createdAt:     map[*connection]time.Time{},
+	}
+	if cp.poolConfig.IdleTimeout > 0 || cp.poolConfig.MaxConnLifetime > 0 {
+		go cp.reaper()
 	}
-
 	return cp
 }
-func (cp *connPool) push(conn *connection) {
-	if conn != nil && conn.closed {
-		// Connection was closed likely due to an error.
-		// Don't attempt to reuse closed connections.
-		conn = nil
+
+func (cp *connPool) reaper() {
+	ticker := time.NewTicker(cp.poolConfig.ReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-cp.stopReap:
+			return
+		case <-ticker.C:
+			cp.reapOnce()
+		}
+	}
+}
+
+func (cp *connPool) reapOnce() {
+	now := time.Now()
+
+	cp.l.Lock()
+	removable := len(cp.idle) - cp.poolConfig.MinIdleConns
+
+	kept := cp.idle[:0]
+	for _, pc := range cp.idle {
+		stale := (cp.poolConfig.IdleTimeout > 0 && now.Sub(pc.lastUsedAt) > cp.poolConfig.IdleTimeout) ||
+			(cp.poolConfig.MaxConnLifetime > 0 && now.Sub(pc.createdAt) > cp.poolConfig.MaxConnLifetime)
+		if stale && removable > 0 {
+			pc.conn.Close()
+			delete(cp.createdAt, pc.conn)
+			cp.numOpen--
+			cp.stats.StaleConns++
+			removable--
+			continue
+		}
+		kept = append(kept, pc)
+	}
+	cp.idle = kept
+	cp.l.Unlock()
+}
+
+// push returns conn to the pool for reuse, or closes it if it's unfit to
+// keep (already closed, over MaxConnLifetime, or the idle set is already at
+// MaxIdleConns). hadErr should be true if conn was seen to return an I/O
+// error while checked out, so pull health-checks it before handing it out
+// again rather than trusting it solely based on age.
+func (cp *connPool) push(conn *connection, hadErr bool) {
+	cp.l.Lock()
+	defer cp.l.Unlock()
+
+	if conn == nil {
+		cp.numOpen--
+		cp.notifyWaiterLocked()
+		return
 	}
 
-	cp.lock.Lock()
-	for cp.size == cp.capacity {
-		cp.fullCond.Wait()
+	createdAt, ok := cp.createdAt[conn]
+	if !ok {
+		createdAt = time.Now()
+		cp.createdAt[conn] = createdAt
 	}
 
-	cp.pool[cp.size] = conn
-	cp.size++
+	stale := cp.poolConfig.MaxConnLifetime > 0 && time.Since(createdAt) > cp.poolConfig.MaxConnLifetime
+	if conn.closed || cp.closed || stale || len(cp.idle) >= cp.poolConfig.MaxIdleConns {
+		conn.Close()
+		delete(cp.createdAt, conn)
+		cp.numOpen--
+		cp.notifyWaiterLocked()
+		return
+	}
 
-	cp.emptyCond.Signal()
-	cp.lock.Unlock()
+	cp.idle = append(cp.idle, &pooledConn{conn: conn, createdAt: createdAt, lastUsedAt: time.Now(), hadErr: hadErr})
+	cp.notifyWaiterLocked()
 }
 
-func (cp *connPool) pull() (*connection, *Error) {
-	var err *Error
+// removeWaiterLocked drops waiter from cp.waiters if it's still queued
+// there. push may have already popped and closed it in a race with ctx.Done
+// firing, in which case there's nothing to remove. cp.l must be held.
+func (cp *connPool) removeWaiterLocked(waiter chan struct{}) {
+	for i, w := range cp.waiters {
+		if w == waiter {
+			cp.waiters = append(cp.waiters[:i], cp.waiters[i+1:]...)
+			return
+		}
+	}
+}
 
-	cp.lock.Lock()
-	for cp.size == 0 {
-		cp.emptyCond.Wait()
+// notifyWaiterLocked wakes one blocked pull, if any. cp.l must be held.
+func (cp *connPool) notifyWaiterLocked() {
+	if len(cp.waiters) == 0 {
+		return
 	}
+	ch := cp.waiters[0]
+	cp.waiters = cp.waiters[1:]
+	close(ch)
+}
 
-	conn := cp.pool[cp.size-1]
-	if conn == nil {
-		// Lazy init of a connection
-		conn, err = newConnection(cp.configuration)
+// pull acquires a connection from the pool, creating a new one if none are
+// idle and the pool isn't at capacity. It blocks until a connection is
+// available, ctx is done (returning ErrPoolTimeout if its deadline passed,
+// or ctx.Err() if it was canceled), or the pool is closed.
+func (cp *connPool) pull(ctx context.Context) (*connection, error) {
+	for {
+		cp.l.Lock()
+		if cp.closed {
+			cp.l.Unlock()
+			return nil, ErrPoolClosed
+		}
+
+		if n := len(cp.idle); n > 0 {
+			pc := cp.idle[n-1]
+			cp.idle = cp.idle[:n-1]
+			cp.stats.Hits++
+			cp.l.Unlock()
 
-		if err != nil {
-			cp.lock.Unlock()
-			return nil, err
+			if cp.needsHealthCheck(pc) && pc.conn.Ping() != nil {
+				pc.conn.Close()
+				cp.l.Lock()
+				delete(cp.createdAt, pc.conn)
+				cp.numOpen--
+				cp.l.Unlock()
+				continue
+			}
+			return pc.conn, nil
+		}
+
+		if cp.configuration.PoolSize == 0 || cp.numOpen < cp.configuration.PoolSize {
+			cp.numOpen++
+			cp.stats.Misses++
+			cp.l.Unlock()
+
+			conn, err := newConnection(cp.configuration)
+			if err != nil {
+				cp.l.Lock()
+				cp.numOpen--
+				cp.l.Unlock()
+				return nil, err
+			}
+
+			cp.l.Lock()
+			cp.createdAt[conn] = time.Now()
+			cp.l.Unlock()
+			return conn, nil
+		}
+
+		waiter := make(chan struct{})
+		cp.waiters = append(cp.waiters, waiter)
+		cp.l.Unlock()
+
+		select {
+		case <-waiter:
+			// loop around and try again now that a slot opened up
+		case <-ctx.Done():
+			cp.l.Lock()
+			cp.removeWaiterLocked(waiter)
+			cp.stats.Timeouts++
+			cp.l.Unlock()
+			if ctx.Err() == context.DeadlineExceeded {
+				return nil, ErrPoolTimeout
+			}
+			return nil, ctx.Err()
 		}
 	}
+}
 
-	cp.size--
-	cp.fullCond.Signal()
-	cp.lock.Unlock()
+func (cp *connPool) needsHealthCheck(pc *pooledConn) bool {
+	if pc.hadErr {
+		return true
+	}
+	threshold := cp.poolConfig.HealthCheckThreshold
+	return threshold > 0 && time.Since(pc.lastUsedAt) > threshold
+}
 
-	return conn, nil
-}
\ No newline at end of file
+// PoolStats returns a snapshot of the pool's activity and current size.
+func (cp *connPool) PoolStats() PoolStats {
+	cp.l.Lock()
+	defer cp.l.Unlock()
+
+	stats := cp.stats
+	stats.TotalConns = uint32(cp.numOpen)
+	stats.IdleConns = uint32(len(cp.idle))
+	return stats
+}
+
+// Close stops the reaper and closes every idle connection. Connections
+// currently checked out are closed as they're returned via push.
+func (cp *connPool) Close() error {
+	cp.closeOnce.Do(func() {
+		close(cp.stopReap)
+
+		cp.l.Lock()
+		defer cp.l.Unlock()
+		cp.closed = true
+		for _, pc := range cp.idle {
+			pc.conn.Close()
+			delete(cp.createdAt, pc.conn)
+		}
+		cp.idle = nil
+		for _, w := range cp.waiters {
+			close(w)
+		}
+		cp.waiters = nil
+	})
+	return nil
+}
@@ -0,0 +1,54 @@
+package radix
+
+import "testing"
+
+func TestRemoveWaiterLockedRemovesMatch(t *testing.T) {
+	cp := &connPool{}
+	w1, w2, w3 := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	cp.waiters = []chan struct{}{w1, w2, w3}
+
+	cp.removeWaiterLocked(w2)
+
+	if len(cp.waiters) != 2 {
+		t.Fatalf("expected 2 waiters left, got %d", len(cp.waiters))
+	}
+	for _, w := range cp.waiters {
+		if w == w2 {
+			t.Fatal("w2 should have been removed")
+		}
+	}
+}
+
+func TestRemoveWaiterLockedNoMatchIsNoop(t *testing.T) {
+	cp := &connPool{}
+	w1 := make(chan struct{})
+	cp.waiters = []chan struct{}{w1}
+
+	// push may have already popped+closed a different waiter concurrently
+	// with ctx.Done firing; removing one no longer present must be a no-op.
+	cp.removeWaiterLocked(make(chan struct{}))
+
+	if len(cp.waiters) != 1 || cp.waiters[0] != w1 {
+		t.Fatalf("removeWaiterLocked touched an unrelated waiter: %v", cp.waiters)
+	}
+}
+
+// TestNotifyWaiterLockedSkipsRemovedWaiter is a regression test for the bug
+// where an abandoned (ctx.Done) waiter left in cp.waiters could absorb a
+// wakeup meant for the next genuinely-blocked pull. With the waiter removed
+// on abandonment, as pull now does, notifyWaiterLocked must wake the
+// remaining live one instead.
+func TestNotifyWaiterLockedSkipsRemovedWaiter(t *testing.T) {
+	cp := &connPool{}
+	dead, live := make(chan struct{}), make(chan struct{})
+	cp.waiters = []chan struct{}{dead, live}
+
+	cp.removeWaiterLocked(dead)
+	cp.notifyWaiterLocked()
+
+	select {
+	case <-live:
+	default:
+		t.Fatal("notifyWaiterLocked should have woken the live waiter")
+	}
+}
@@ -0,0 +1,122 @@
+// Package redistrace provides a radix.Hook which emits OpenTelemetry spans
+// for every command and pipeline a radix.Client runs.
+package redistrace
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mediocregopher/radix.v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultTracerName is used as the instrumentation name passed to
+// otel.Tracer when no Tracer is given via WithTracer.
+const defaultTracerName = "github.com/mediocregopher/radix.v3/redistrace"
+
+type hookOpts struct {
+	tracer trace.Tracer
+}
+
+// Option customizes the behavior of NewHook.
+type Option func(*hookOpts)
+
+// WithTracer sets the trace.Tracer used to start spans. If not given, one is
+// obtained from the global TracerProvider using the package's import path as
+// the instrumentation name.
+func WithTracer(t trace.Tracer) Option {
+	return func(o *hookOpts) { o.tracer = t }
+}
+
+// NewHook returns a radix.Hook which starts a span for every command and
+// pipeline it sees, finishing the span in the corresponding After hook.
+// Command spans are tagged with db.system=redis, db.statement set to the
+// command as it would be sent to redis, and the resolved keys (if any) as an
+// attribute. Pipeline spans are tagged with db.system=redis only.
+func NewHook(opts ...Option) radix.Hook {
+	o := hookOpts{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.tracer == nil {
+		o.tracer = otel.Tracer(defaultTracerName)
+	}
+	return &hook{hookOpts: o}
+}
+
+type hook struct {
+	radix.HookBase
+	hookOpts
+}
+
+func (h *hook) BeforeProcess(ctx context.Context, cmd radix.CmdAction) (context.Context, error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "redis"),
+		attribute.StringSlice("db.redis.keys", cmd.Keys()),
+	}
+	if s, ok := cmdString(cmd); ok {
+		attrs = append(attrs, attribute.String("db.statement", s))
+	}
+	ctx, _ = h.tracer.Start(ctx, spanName(cmd), trace.WithAttributes(attrs...))
+	return ctx, nil
+}
+
+func (h *hook) AfterProcess(ctx context.Context, cmd radix.CmdAction, err error) error {
+	endSpan(ctx, err)
+	return err
+}
+
+func (h *hook) BeforeProcessPipeline(ctx context.Context, cmds []radix.CmdAction) (context.Context, error) {
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = spanName(cmd)
+	}
+	ctx, _ = h.tracer.Start(ctx, "pipeline "+strings.Join(names, ","), trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+	))
+	return ctx, nil
+}
+
+func (h *hook) AfterProcessPipeline(ctx context.Context, cmds []radix.CmdAction, err error) error {
+	endSpan(ctx, err)
+	return err
+}
+
+func spanName(cmd radix.CmdAction) string {
+	s, ok := cmdString(cmd)
+	if !ok {
+		// CmdAction doesn't require String(); fall back to something that
+		// still identifies the span without guessing at the command name.
+		if keys := cmd.Keys(); len(keys) > 0 {
+			return "redis " + keys[0]
+		}
+		return "redis"
+	}
+	if i := strings.IndexByte(s, ' '); i > 0 {
+		return strings.Trim(s[1:i], `"`)
+	}
+	return s
+}
+
+// cmdString returns cmd's String() representation, if it implements one.
+// CmdAction doesn't require String(), so a Hook can't assume every
+// implementation has it.
+func cmdString(cmd radix.CmdAction) (string, bool) {
+	s, ok := cmd.(interface{ String() string })
+	if !ok {
+		return "", false
+	}
+	return s.String(), true
+}
+
+func endSpan(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,830 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Marshaler is implemented by any type which can marshal itself into a
+// RESP-encoded message.
+type Marshaler interface {
+	MarshalRESP(w io.Writer) error
+}
+
+// Unmarshaler is implemented by any type which can unmarshal a RESP-encoded
+// message describing itself out of a buffered reader.
+type Unmarshaler interface {
+	UnmarshalRESP(br *bufio.Reader) error
+}
+
+// RESP2 type prefixes. The RESP3 prefixes (Map/Set/Double/...) live alongside
+// the types that use them in resp3.go.
+const (
+	simpleStrPrefix = '+'
+	errPrefix       = '-'
+	intPrefix       = ':'
+	bulkStrPrefix   = '$'
+	arrPrefix       = '*'
+)
+
+var delim = []byte("\r\n")
+
+var (
+	posInf = math.Inf(1)
+	negInf = math.Inf(-1)
+)
+
+// readLine reads up to and including the next "\r\n", returning the bytes
+// before it. The returned slice is a copy, safe to hold onto across further
+// reads from br.
+func readLine(br *bufio.Reader) ([]byte, error) {
+	b, err := br.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 2 || b[len(b)-2] != '\r' {
+		return nil, errors.New("resp: malformed line, missing \\r\\n")
+	}
+	line := make([]byte, len(b)-2)
+	copy(line, b[:len(b)-2])
+	return line, nil
+}
+
+// readPrefixedLine reads a line via readLine and verifies it begins with
+// prefix, returning the line with that prefix stripped.
+func readPrefixedLine(br *bufio.Reader, prefix byte) ([]byte, error) {
+	line, err := readLine(br)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != prefix {
+		return nil, fmt.Errorf("resp: expected message starting with %q, got %q", prefix, line)
+	}
+	return line[1:], nil
+}
+
+// readSimpleBody reads the body of a line-based RESP reply (a RESP2 simple
+// string/error/integer, or a RESP3 scalar like Double/Boolean/BigNumber/Null)
+// which begins with prefix.
+func readSimpleBody(br *bufio.Reader, prefix byte) ([]byte, error) {
+	return readPrefixedLine(br, prefix)
+}
+
+// readBulkBody reads the body of a length-prefixed RESP reply (a RESP2 bulk
+// string, or a RESP3 verbatim string) which begins with prefix. A length of
+// -1 (a null bulk string) yields a nil body and no error.
+func readBulkBody(br *bufio.Reader, prefix byte) ([]byte, error) {
+	head, err := readPrefixedLine(br, prefix)
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(string(head))
+	if err != nil {
+		return nil, fmt.Errorf("resp: malformed bulk length %q: %w", head, err)
+	}
+	if n < 0 {
+		return nil, nil
+	}
+	body := make([]byte, n+2)
+	if _, err := io.ReadFull(br, body); err != nil {
+		return nil, err
+	}
+	return body[:n], nil
+}
+
+// readArrayHeaderBody reads the header of a count-prefixed RESP reply (a
+// RESP2 array, or a RESP3 map/set/push) which begins with prefix, and returns
+// the count. A count of -1 (a null array) is returned as 0.
+func readArrayHeaderBody(br *bufio.Reader, prefix byte) (int, error) {
+	head, err := readPrefixedLine(br, prefix)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(string(head))
+	if err != nil {
+		return 0, fmt.Errorf("resp: malformed array length %q: %w", head, err)
+	}
+	if n < 0 {
+		return 0, nil
+	}
+	return n, nil
+}
+
+// readRawMessage reads one complete RESP message of any type, starting at
+// its prefix byte, and returns the exact bytes which made it up without
+// interpreting them. It recurses into arrays/maps/sets/pushes to consume
+// their elements, but doesn't otherwise care what those elements mean.
+func readRawMessage(br *bufio.Reader) ([]byte, error) {
+	head, err := br.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(head) < 3 || head[len(head)-2] != '\r' {
+		return nil, errors.New("resp: malformed message, missing \\r\\n")
+	}
+	full := make([]byte, len(head))
+	copy(full, head)
+
+	prefix := head[0]
+	countOrLen := head[1 : len(head)-2]
+
+	switch prefix {
+	case simpleStrPrefix, errPrefix, intPrefix,
+		doublePrefix, booleanPrefix, bigNumberPrefix, nullPrefix:
+		return full, nil
+
+	case bulkStrPrefix, verbatimStringPrefix:
+		n, err := strconv.Atoi(string(countOrLen))
+		if err != nil {
+			return nil, fmt.Errorf("resp: malformed bulk length %q: %w", countOrLen, err)
+		}
+		if n < 0 {
+			return full, nil
+		}
+		body := make([]byte, n+2)
+		if _, err := io.ReadFull(br, body); err != nil {
+			return nil, err
+		}
+		return append(full, body...), nil
+
+	case arrPrefix, mapPrefix, setPrefix, pushPrefix:
+		n, err := strconv.Atoi(string(countOrLen))
+		if err != nil {
+			return nil, fmt.Errorf("resp: malformed array length %q: %w", countOrLen, err)
+		}
+		if n < 0 {
+			return full, nil
+		}
+		count := n
+		if prefix == mapPrefix {
+			count = n * 2
+		}
+		for i := 0; i < count; i++ {
+			elem, err := readRawMessage(br)
+			if err != nil {
+				return nil, err
+			}
+			full = append(full, elem...)
+		}
+		return full, nil
+
+	default:
+		return nil, fmt.Errorf("resp: unknown message type %q", prefix)
+	}
+}
+
+func marshalNilBulkString(w io.Writer) error {
+	_, err := w.Write([]byte("$-1\r\n"))
+	return err
+}
+
+func marshalBulkBytes(w io.Writer, b []byte) error {
+	head := append([]byte{bulkStrPrefix}, strconv.Itoa(len(b))...)
+	head = append(head, delim...)
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	_, err := w.Write(delim)
+	return err
+}
+
+func marshalInteger(w io.Writer, s string) error {
+	b := append([]byte{intPrefix}, s...)
+	b = append(b, delim...)
+	_, err := w.Write(b)
+	return err
+}
+
+// marshalScalar writes s as a bulk string if bulk is true; otherwise, if
+// isInt is true, it's written as a RESP integer, and as a bulk string
+// otherwise (RESP2 has no native float/boolean type).
+func marshalScalar(w io.Writer, s string, bulk, isInt bool) error {
+	if !bulk && isInt {
+		return marshalInteger(w, s)
+	}
+	return marshalBulkBytes(w, []byte(s))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ArrayHeader marshals/unmarshals just the header of a RESP2 array (e.g.
+// "*3\r\n"), letting a caller stream the elements itself rather than
+// buffering them all up-front.
+type ArrayHeader struct {
+	N int
+}
+
+// MarshalRESP implements the Marshaler interface.
+func (h ArrayHeader) MarshalRESP(w io.Writer) error {
+	return h.marshalPrefixed(w, arrPrefix)
+}
+
+// marshalPrefixed is like MarshalRESP, but using prefix in place of the
+// RESP2 array prefix; used by the RESP3 Map/Set/Push types, whose headers
+// share ArrayHeader's "count then \r\n" shape but with their own prefix
+// byte.
+func (h ArrayHeader) marshalPrefixed(w io.Writer, prefix byte) error {
+	b := append([]byte{prefix}, strconv.Itoa(h.N)...)
+	b = append(b, delim...)
+	_, err := w.Write(b)
+	return err
+}
+
+// UnmarshalRESP implements the Unmarshaler interface.
+func (h *ArrayHeader) UnmarshalRESP(br *bufio.Reader) error {
+	n, err := readArrayHeaderBody(br, arrPrefix)
+	if err != nil {
+		return err
+	}
+	h.N = n
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BulkString is a RESP2 bulk string, e.g. "$3\r\nfoo\r\n".
+type BulkString struct {
+	S string
+}
+
+// MarshalRESP implements the Marshaler interface.
+func (b BulkString) MarshalRESP(w io.Writer) error {
+	return marshalBulkBytes(w, []byte(b.S))
+}
+
+// UnmarshalRESP implements the Unmarshaler interface.
+func (b *BulkString) UnmarshalRESP(br *bufio.Reader) error {
+	body, err := readBulkBody(br, bulkStrPrefix)
+	if err != nil {
+		return err
+	}
+	b.S = string(body)
+	return nil
+}
+
+// BulkStringBytes is like BulkString, but for a []byte rather than a string,
+// avoiding an extra copy/allocation when the caller already has one.
+type BulkStringBytes struct {
+	B []byte
+}
+
+// MarshalRESP implements the Marshaler interface.
+func (b BulkStringBytes) MarshalRESP(w io.Writer) error {
+	return marshalBulkBytes(w, b.B)
+}
+
+// UnmarshalRESP implements the Unmarshaler interface.
+func (b *BulkStringBytes) UnmarshalRESP(br *bufio.Reader) error {
+	body, err := readBulkBody(br, bulkStrPrefix)
+	if err != nil {
+		return err
+	}
+	b.B = body
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// RawMessage is a fully marshaled RESP message of any type, captured
+// verbatim rather than interpreted. It's useful when a message's meaning
+// depends on context only available after looking at its shape, e.g. the
+// Scanner in the radix package reads a SCAN reply's 2-element array into
+// [2]RawMessage before interpreting the first element as a cursor and the
+// second as the array of keys.
+type RawMessage []byte
+
+// MarshalRESP implements the Marshaler interface.
+func (rm RawMessage) MarshalRESP(w io.Writer) error {
+	_, err := w.Write(rm)
+	return err
+}
+
+// UnmarshalRESP implements the Unmarshaler interface by copying the next
+// complete RESP message (of any type) into rm, without interpreting it.
+func (rm *RawMessage) UnmarshalRESP(br *bufio.Reader) error {
+	b, err := readRawMessage(br)
+	if err != nil {
+		return err
+	}
+	*rm = b
+	return nil
+}
+
+// UnmarshalInto unmarshals rm's contents into u, as if u.UnmarshalRESP had
+// been called directly against the stream rm was originally read from.
+func (rm RawMessage) UnmarshalInto(u Unmarshaler) error {
+	br := bufio.NewReader(bytes.NewReader(rm))
+	return u.UnmarshalRESP(br)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// LenReader is an io.Reader which also knows its own length, letting it be
+// marshaled as a single RESP bulk string without first being buffered into
+// memory. *bytes.Buffer satisfies this interface.
+type LenReader interface {
+	io.Reader
+	Len() int
+}
+
+// NewLenReader wraps r, whose total remaining length is known to be length,
+// so it can be passed as a LenReader argument (e.g. to radix.FlatCmd).
+func NewLenReader(r io.Reader, length int) LenReader {
+	return &lenReader{r: r, length: length}
+}
+
+type lenReader struct {
+	r      io.Reader
+	length int
+}
+
+func (lr *lenReader) Read(p []byte) (int, error) { return lr.r.Read(p) }
+func (lr *lenReader) Len() int                   { return lr.length }
+
+func marshalLenReader(w io.Writer, lr LenReader) error {
+	n := lr.Len()
+	head := append([]byte{bulkStrPrefix}, strconv.Itoa(n)...)
+	head = append(head, delim...)
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(w, lr, int64(n)); err != nil {
+		return err
+	}
+	_, err := w.Write(delim)
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Any is a generic RESP2 value, capable of marshaling/unmarshaling most Go
+// types into/out of whichever RESP2 message shape fits them (scalars as
+// simple/bulk/integer replies, slices/arrays/maps as array replies).
+//
+// When unmarshaling, I must be a pointer to the destination (a primitive, a
+// slice, a map, or an array of a fixed size), an io.Writer, an
+// encoding.Text/BinaryUnmarshaler, or a resp.Unmarshaler. A nil I discards
+// whatever is read.
+//
+// When marshaling, I may be any of the above (sans pointer-to-slice/map,
+// which also work directly), a resp.Marshaler, an encoding.Text/
+// BinaryMarshaler, or a LenReader.
+type Any struct {
+	I interface{}
+
+	// MarshalBulkString causes any scalar to be marshaled as a RESP bulk
+	// string rather than its natural RESP type (e.g. an int would otherwise
+	// marshal as a RESP integer).
+	MarshalBulkString bool
+
+	// MarshalNoArrayHeaders, when I is a slice/map/array, causes MarshalRESP
+	// to write only the flattened elements themselves, without a leading
+	// array header. Used when the caller already wrote its own header
+	// covering these elements alongside others (e.g. FlatCmd's cmd/key, or
+	// a RESP3 Map/Set/Push's own prefix).
+	MarshalNoArrayHeaders bool
+}
+
+// NumElems returns the number of RESP elements I will flatten into: 1 for a
+// scalar, or the (possibly recursive) total count of elements for a
+// slice/array/map.
+func (a Any) NumElems() int {
+	return numElems(reflect.ValueOf(a.I))
+}
+
+func numElems(v reflect.Value) int {
+	if !v.IsValid() {
+		return 1
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return 1
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return 1 // []byte/[N]byte marshal as a single bulk string
+		}
+		n := 0
+		for i := 0; i < v.Len(); i++ {
+			n += numElems(v.Index(i))
+		}
+		return n
+	case reflect.Map:
+		n := 0
+		iter := v.MapRange()
+		for iter.Next() {
+			n += numElems(iter.Key()) + numElems(iter.Value())
+		}
+		return n
+	default:
+		return 1
+	}
+}
+
+// MarshalRESP implements the Marshaler interface.
+func (a Any) MarshalRESP(w io.Writer) error {
+	v := reflect.ValueOf(a.I)
+	if !a.MarshalNoArrayHeaders && isContainerValue(v) {
+		if err := (ArrayHeader{N: numElems(v)}).MarshalRESP(w); err != nil {
+			return err
+		}
+	}
+	return marshalAnyValue(w, v, a.MarshalBulkString)
+}
+
+func isContainerValue(v reflect.Value) bool {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Slice, reflect.Array:
+		return v.Type().Elem().Kind() != reflect.Uint8
+	default:
+		return false
+	}
+}
+
+func marshalAnyValue(w io.Writer, v reflect.Value, bulk bool) error {
+	if !v.IsValid() {
+		return marshalNilBulkString(w)
+	}
+
+	for {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return marshalNilBulkString(w)
+		}
+		if v.CanInterface() {
+			switch i := v.Interface().(type) {
+			case Marshaler:
+				return i.MarshalRESP(w)
+			case LenReader:
+				return marshalLenReader(w, i)
+			case encoding.TextMarshaler:
+				b, err := i.MarshalText()
+				if err != nil {
+					return err
+				}
+				return marshalBulkBytes(w, b)
+			case encoding.BinaryMarshaler:
+				b, err := i.MarshalBinary()
+				if err != nil {
+					return err
+				}
+				return marshalBulkBytes(w, b)
+			}
+		}
+		if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
+			break
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Kind() == reflect.Array {
+				b := make([]byte, v.Len())
+				reflect.Copy(reflect.ValueOf(b), v)
+				return marshalBulkBytes(w, b)
+			}
+			return marshalBulkBytes(w, v.Bytes())
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := marshalAnyValue(w, v.Index(i), bulk); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := marshalAnyValue(w, iter.Key(), bulk); err != nil {
+				return err
+			}
+			if err := marshalAnyValue(w, iter.Value(), bulk); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.String:
+		return marshalScalar(w, v.String(), bulk, false)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return marshalScalar(w, strconv.FormatInt(v.Int(), 10), bulk, true)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return marshalScalar(w, strconv.FormatUint(v.Uint(), 10), bulk, true)
+	case reflect.Float32, reflect.Float64:
+		return marshalScalar(w, strconv.FormatFloat(v.Float(), 'f', -1, 64), bulk, false)
+	case reflect.Bool:
+		s := "0"
+		if v.Bool() {
+			s = "1"
+		}
+		return marshalScalar(w, s, bulk, true)
+	default:
+		return fmt.Errorf("resp: can't marshal value of type %s", v.Type())
+	}
+}
+
+// UnmarshalRESP implements the Unmarshaler interface.
+func (a Any) UnmarshalRESP(br *bufio.Reader) error {
+	if u, ok := a.I.(Unmarshaler); ok {
+		return u.UnmarshalRESP(br)
+	}
+
+	b, err := br.Peek(1)
+	if err != nil {
+		return err
+	}
+
+	switch b[0] {
+	case simpleStrPrefix:
+		body, err := readSimpleBody(br, simpleStrPrefix)
+		if err != nil {
+			return err
+		}
+		return assignString(a.I, body)
+	case errPrefix:
+		body, err := readSimpleBody(br, errPrefix)
+		if err != nil {
+			return err
+		}
+		return errors.New(string(body))
+	case intPrefix:
+		body, err := readSimpleBody(br, intPrefix)
+		if err != nil {
+			return err
+		}
+		n, err := strconv.ParseInt(string(body), 10, 64)
+		if err != nil {
+			return err
+		}
+		return assignInt(a.I, n)
+	case bulkStrPrefix:
+		body, err := readBulkBody(br, bulkStrPrefix)
+		if err != nil {
+			return err
+		}
+		if body == nil {
+			return assignNil(a.I)
+		}
+		return assignString(a.I, body)
+	case arrPrefix:
+		n, err := readArrayHeaderBody(br, arrPrefix)
+		if err != nil {
+			return err
+		}
+		return a.unmarshalArrayBody(br, n)
+	default:
+		return fmt.Errorf("resp: unexpected message type %q", b[0])
+	}
+}
+
+// unmarshalArrayBody fills a.I (a pointer to a slice, array, map, or struct,
+// or nil to discard) from the next n RESP elements in br. It's called after
+// the array/map/set/push header (of whichever RESP type) has already been
+// read and its count translated into n flat elements.
+func (a Any) unmarshalArrayBody(br *bufio.Reader, n int) error {
+	if a.I == nil {
+		for i := 0; i < n; i++ {
+			if err := (Any{}).UnmarshalRESP(br); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(a.I)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("resp: destination must be a non-nil pointer, got %T", a.I)
+	}
+	elem := rv.Elem()
+
+	switch elem.Kind() {
+	case reflect.Slice:
+		elemType := elem.Type().Elem()
+		slice := reflect.MakeSlice(elem.Type(), n, n)
+		for i := 0; i < n; i++ {
+			ev := reflect.New(elemType)
+			if err := (Any{I: ev.Interface()}).UnmarshalRESP(br); err != nil {
+				return err
+			}
+			slice.Index(i).Set(ev.Elem())
+		}
+		elem.Set(slice)
+		return nil
+
+	case reflect.Array:
+		if elem.Len() != n {
+			for i := 0; i < n; i++ {
+				if err := (Any{}).UnmarshalRESP(br); err != nil {
+					return err
+				}
+			}
+			return fmt.Errorf("resp: array reply has %d elements, destination is [%d]%s", n, elem.Len(), elem.Type().Elem())
+		}
+		elemType := elem.Type().Elem()
+		for i := 0; i < n; i++ {
+			ev := reflect.New(elemType)
+			if err := (Any{I: ev.Interface()}).UnmarshalRESP(br); err != nil {
+				return err
+			}
+			elem.Index(i).Set(ev.Elem())
+		}
+		return nil
+
+	case reflect.Map:
+		if n%2 != 0 {
+			return errors.New("resp: map-shaped reply has an odd number of elements")
+		}
+		if elem.IsNil() {
+			elem.Set(reflect.MakeMap(elem.Type()))
+		}
+		keyType, valType := elem.Type().Key(), elem.Type().Elem()
+		for i := 0; i < n; i += 2 {
+			kv := reflect.New(keyType)
+			if err := (Any{I: kv.Interface()}).UnmarshalRESP(br); err != nil {
+				return err
+			}
+			vv := reflect.New(valType)
+			if err := (Any{I: vv.Interface()}).UnmarshalRESP(br); err != nil {
+				return err
+			}
+			elem.SetMapIndex(kv.Elem(), vv.Elem())
+		}
+		return nil
+
+	case reflect.Struct:
+		if n%2 != 0 {
+			return errors.New("resp: map-shaped reply has an odd number of elements")
+		}
+		for i := 0; i < n; i += 2 {
+			var key string
+			if err := (Any{I: &key}).UnmarshalRESP(br); err != nil {
+				return err
+			}
+			fv := elem.FieldByNameFunc(func(name string) bool {
+				return strings.EqualFold(name, key)
+			})
+			if !fv.IsValid() || !fv.CanSet() {
+				if err := (Any{}).UnmarshalRESP(br); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := (Any{I: fv.Addr().Interface()}).UnmarshalRESP(br); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Interface:
+		s := make([]interface{}, n)
+		for i := range s {
+			if err := (Any{I: &s[i]}).UnmarshalRESP(br); err != nil {
+				return err
+			}
+		}
+		elem.Set(reflect.ValueOf(s))
+		return nil
+
+	default:
+		return fmt.Errorf("resp: can't unmarshal array reply into %s", elem.Type())
+	}
+}
+
+func assignString(dst interface{}, raw []byte) error {
+	if dst == nil {
+		return nil
+	}
+	if w, ok := dst.(io.Writer); ok {
+		_, err := w.Write(raw)
+		return err
+	}
+	if tu, ok := dst.(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText(raw)
+	}
+	if bu, ok := dst.(encoding.BinaryUnmarshaler); ok {
+		return bu.UnmarshalBinary(raw)
+	}
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("resp: destination must be a non-nil pointer, got %T", dst)
+	}
+	return setReflectFromString(rv.Elem(), string(raw))
+}
+
+func setReflectFromString(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("resp: can't unmarshal a string into %s", v.Type())
+		}
+		v.SetBytes([]byte(s))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(s))
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setReflectFromString(v.Elem(), s)
+	default:
+		return fmt.Errorf("resp: can't unmarshal a string into %s", v.Type())
+	}
+	return nil
+}
+
+func assignInt(dst interface{}, n int64) error {
+	if dst == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("resp: destination must be a non-nil pointer, got %T", dst)
+	}
+	return setReflectFromInt(rv.Elem(), n)
+}
+
+func setReflectFromInt(v reflect.Value, n int64) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(n))
+	case reflect.Bool:
+		v.SetBool(n != 0)
+	case reflect.String:
+		v.SetString(strconv.FormatInt(n, 10))
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(n))
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setReflectFromInt(v.Elem(), n)
+	default:
+		return fmt.Errorf("resp: can't unmarshal an integer into %s", v.Type())
+	}
+	return nil
+}
+
+func assignNil(dst interface{}) error {
+	if dst == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("resp: destination must be a non-nil pointer, got %T", dst)
+	}
+	rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+	return nil
+}
@@ -0,0 +1,155 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func marshal(t *testing.T, m Marshaler) string {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	if err := m.MarshalRESP(buf); err != nil {
+		t.Fatalf("MarshalRESP: %v", err)
+	}
+	return buf.String()
+}
+
+func TestAnyMarshalScalars(t *testing.T) {
+	for _, tt := range []struct {
+		in   interface{}
+		bulk bool
+		want string
+	}{
+		{"foo", true, "$3\r\nfoo\r\n"},
+		{42, false, ":42\r\n"},
+		{42, true, "$2\r\n42\r\n"},
+		{[]byte("bar"), true, "$3\r\nbar\r\n"},
+	} {
+		a := Any{I: tt.in, MarshalBulkString: tt.bulk}
+		if got := marshal(t, a); got != tt.want {
+			t.Errorf("Any{I: %#v, MarshalBulkString: %v} = %q, want %q", tt.in, tt.bulk, got, tt.want)
+		}
+	}
+}
+
+func TestAnyMarshalSliceWithHeader(t *testing.T) {
+	a := Any{I: []string{"a", "bb"}, MarshalBulkString: true}
+	want := "*2\r\n$1\r\na\r\n$2\r\nbb\r\n"
+	if got := marshal(t, a); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAnyMarshalNoArrayHeaders(t *testing.T) {
+	a := Any{I: []string{"a", "bb"}, MarshalBulkString: true, MarshalNoArrayHeaders: true}
+	want := "$1\r\na\r\n$2\r\nbb\r\n"
+	if got := marshal(t, a); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func unmarshal(t *testing.T, s string, rcv interface{}) error {
+	t.Helper()
+	br := bufio.NewReader(bytes.NewBufferString(s))
+	return (Any{I: rcv}).UnmarshalRESP(br)
+}
+
+func TestAnyUnmarshalScalars(t *testing.T) {
+	var s string
+	if err := unmarshal(t, "$3\r\nfoo\r\n", &s); err != nil {
+		t.Fatalf("UnmarshalRESP: %v", err)
+	} else if s != "foo" {
+		t.Errorf("got %q, want %q", s, "foo")
+	}
+
+	var n int
+	if err := unmarshal(t, ":42\r\n", &n); err != nil {
+		t.Fatalf("UnmarshalRESP: %v", err)
+	} else if n != 42 {
+		t.Errorf("got %d, want 42", n)
+	}
+
+	var ss []string
+	if err := unmarshal(t, "*2\r\n$1\r\na\r\n$2\r\nbb\r\n", &ss); err != nil {
+		t.Fatalf("UnmarshalRESP: %v", err)
+	}
+	if len(ss) != 2 || ss[0] != "a" || ss[1] != "bb" {
+		t.Errorf("got %v, want [a bb]", ss)
+	}
+
+	var m map[string]int
+	if err := unmarshal(t, "*4\r\n$1\r\na\r\n:1\r\n$1\r\nb\r\n:2\r\n", &m); err != nil {
+		t.Fatalf("UnmarshalRESP: %v", err)
+	}
+	if m["a"] != 1 || m["b"] != 2 {
+		t.Errorf("got %v, want map[a:1 b:2]", m)
+	}
+}
+
+func TestAnyUnmarshalNilBulkStringZeroesDestination(t *testing.T) {
+	s := "preexisting"
+	if err := unmarshal(t, "$-1\r\n", &s); err != nil {
+		t.Fatalf("UnmarshalRESP: %v", err)
+	}
+	if s != "" {
+		t.Errorf("got %q, want empty string", s)
+	}
+}
+
+func TestAnyUnmarshalErrorReply(t *testing.T) {
+	var s string
+	err := unmarshal(t, "-NOSCRIPT No matching script\r\n", &s)
+	if err == nil || err.Error() != "NOSCRIPT No matching script" {
+		t.Fatalf("got %v, want NOSCRIPT error", err)
+	}
+}
+
+func TestAnyUnmarshalIntoInterfaceSlice(t *testing.T) {
+	var vals []interface{}
+	if err := unmarshal(t, "*2\r\n$1\r\na\r\n:1\r\n", &vals); err != nil {
+		t.Fatalf("UnmarshalRESP: %v", err)
+	}
+	if len(vals) != 2 || vals[0] != "a" || vals[1] != int64(1) {
+		t.Fatalf("got %v, want [a 1 (int64)]", vals)
+	}
+}
+
+func TestRawMessageUnmarshalIntoAndRoundtrip(t *testing.T) {
+	br := bufio.NewReader(bytes.NewBufferString("*2\r\n$1\r\n0\r\n*2\r\n$1\r\na\r\n$1\r\nb\r\n"))
+	var arr [2]RawMessage
+	if err := (Any{I: &arr}).UnmarshalRESP(br); err != nil {
+		t.Fatalf("UnmarshalRESP: %v", err)
+	}
+
+	var cursor string
+	if err := arr[0].UnmarshalInto(Any{I: &cursor}); err != nil {
+		t.Fatalf("UnmarshalInto: %v", err)
+	}
+	if cursor != "0" {
+		t.Errorf("got cursor %q, want %q", cursor, "0")
+	}
+
+	var elems []string
+	if err := arr[1].UnmarshalInto(Any{I: &elems}); err != nil {
+		t.Fatalf("UnmarshalInto: %v", err)
+	}
+	if len(elems) != 2 || elems[0] != "a" || elems[1] != "b" {
+		t.Errorf("got %v, want [a b]", elems)
+	}
+}
+
+func TestArrayHeaderMarshalUnmarshal(t *testing.T) {
+	if got := marshal(t, ArrayHeader{N: 3}); got != "*3\r\n" {
+		t.Errorf("got %q, want %q", got, "*3\r\n")
+	}
+
+	br := bufio.NewReader(bytes.NewBufferString("*5\r\n"))
+	var h ArrayHeader
+	if err := h.UnmarshalRESP(br); err != nil {
+		t.Fatalf("UnmarshalRESP: %v", err)
+	}
+	if h.N != 5 {
+		t.Errorf("got N=%d, want 5", h.N)
+	}
+}
@@ -0,0 +1,279 @@
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math/big"
+	"strconv"
+)
+
+// This file contains the RESP3 types which are not present in RESP2:
+// Map, Set, Double, Boolean, BigNumber, VerbatimString, Null, and Push. They
+// follow the same Marshal(RESP)/Unmarshal(RESP) conventions as the RESP2
+// types in resp2.go, and are only produced/consumed when a connection has
+// completed a RESP3 HELLO handshake.
+
+const (
+	mapPrefix            = '%'
+	setPrefix            = '~'
+	doublePrefix         = ','
+	booleanPrefix        = '#'
+	bigNumberPrefix      = '('
+	verbatimStringPrefix = '='
+	nullPrefix           = '_'
+	pushPrefix           = '>'
+)
+
+// Map is a RESP3 type which holds an ordered sequence of key/value pairs. Use
+// it to marshal/unmarshal a flat key/value sequence as a map, rather than an
+// Array as is done in RESP2.
+//
+// When unmarshaling, I must be a pointer to a map, or a pointer to a struct
+// which will have its fields set from the keys of the Map.
+type Map struct {
+	I interface{}
+}
+
+// MarshalRESP implements the Marshaler interface.
+func (m Map) MarshalRESP(w io.Writer) error {
+	a := Any{I: m.I, MarshalBulkString: true, MarshalNoArrayHeaders: true}
+	n := a.NumElems()
+	if n%2 != 0 {
+		return errors.New("resp3: Map must marshal an even number of elements")
+	}
+	if err := (ArrayHeader{N: n / 2}).marshalPrefixed(w, mapPrefix); err != nil {
+		return err
+	}
+	return a.MarshalRESP(w)
+}
+
+// UnmarshalRESP implements the Unmarshaler interface.
+func (m Map) UnmarshalRESP(br *bufio.Reader) error {
+	pair, err := readArrayHeaderBody(br, mapPrefix)
+	if err != nil {
+		return err
+	}
+	return (Any{I: m.I}).unmarshalArrayBody(br, pair*2)
+}
+
+// Set is a RESP3 type which holds an unordered collection of distinct
+// elements, analogous to an Array but semantically a set. I must be a
+// pointer to a slice, exactly as with Any.
+type Set struct {
+	I interface{}
+}
+
+// MarshalRESP implements the Marshaler interface.
+func (s Set) MarshalRESP(w io.Writer) error {
+	a := Any{I: s.I, MarshalBulkString: true, MarshalNoArrayHeaders: true}
+	if err := (ArrayHeader{N: a.NumElems()}).marshalPrefixed(w, setPrefix); err != nil {
+		return err
+	}
+	return a.MarshalRESP(w)
+}
+
+// UnmarshalRESP implements the Unmarshaler interface.
+func (s Set) UnmarshalRESP(br *bufio.Reader) error {
+	n, err := readArrayHeaderBody(br, setPrefix)
+	if err != nil {
+		return err
+	}
+	return (Any{I: s.I}).unmarshalArrayBody(br, n)
+}
+
+// Double is a RESP3 type for a floating point number, e.g. ",3.14\r\n".
+type Double struct {
+	F float64
+}
+
+// MarshalRESP implements the Marshaler interface.
+func (d Double) MarshalRESP(w io.Writer) error {
+	b := append([]byte{doublePrefix}, strconv.FormatFloat(d.F, 'f', -1, 64)...)
+	b = append(b, delim...)
+	_, err := w.Write(b)
+	return err
+}
+
+// UnmarshalRESP implements the Unmarshaler interface.
+func (d *Double) UnmarshalRESP(br *bufio.Reader) error {
+	b, err := readSimpleBody(br, doublePrefix)
+	if err != nil {
+		return err
+	}
+	switch string(b) {
+	case "inf":
+		d.F = posInf
+	case "-inf":
+		d.F = negInf
+	default:
+		d.F, err = strconv.ParseFloat(string(b), 64)
+	}
+	return err
+}
+
+// Boolean is a RESP3 type for a true/false value, e.g. "#t\r\n".
+type Boolean struct {
+	B bool
+}
+
+// MarshalRESP implements the Marshaler interface.
+func (b Boolean) MarshalRESP(w io.Writer) error {
+	c := byte('f')
+	if b.B {
+		c = 't'
+	}
+	_, err := w.Write([]byte{booleanPrefix, c, '\r', '\n'})
+	return err
+}
+
+// UnmarshalRESP implements the Unmarshaler interface.
+func (b *Boolean) UnmarshalRESP(br *bufio.Reader) error {
+	body, err := readSimpleBody(br, booleanPrefix)
+	if err != nil {
+		return err
+	}
+	if len(body) != 1 || (body[0] != 't' && body[0] != 'f') {
+		return errors.New("resp3: malformed boolean")
+	}
+	b.B = body[0] == 't'
+	return nil
+}
+
+// BigNumber is a RESP3 type for an integer too large for an int64, e.g.
+// "(3492890328409238509324850943850943825024385\r\n".
+type BigNumber struct {
+	I *big.Int
+}
+
+// MarshalRESP implements the Marshaler interface.
+func (n BigNumber) MarshalRESP(w io.Writer) error {
+	b := append([]byte{bigNumberPrefix}, n.I.String()...)
+	b = append(b, delim...)
+	_, err := w.Write(b)
+	return err
+}
+
+// UnmarshalRESP implements the Unmarshaler interface.
+func (n *BigNumber) UnmarshalRESP(br *bufio.Reader) error {
+	body, err := readSimpleBody(br, bigNumberPrefix)
+	if err != nil {
+		return err
+	}
+	if n.I == nil {
+		n.I = new(big.Int)
+	}
+	if _, ok := n.I.SetString(string(body), 10); !ok {
+		return errors.New("resp3: malformed big number")
+	}
+	return nil
+}
+
+// VerbatimString is a RESP3 type for a bulk string which is tagged with its
+// encoding, e.g. "txt" or "mkd". Format will be empty if the string wasn't
+// tagged with one.
+type VerbatimString struct {
+	Format string
+	S      string
+}
+
+// MarshalRESP implements the Marshaler interface.
+func (vs VerbatimString) MarshalRESP(w io.Writer) error {
+	format := vs.Format
+	if format == "" {
+		format = "txt"
+	}
+	body := format + ":" + vs.S
+	b := append([]byte{verbatimStringPrefix}, strconv.Itoa(len(body))...)
+	b = append(b, delim...)
+	b = append(b, body...)
+	b = append(b, delim...)
+	_, err := w.Write(b)
+	return err
+}
+
+// UnmarshalRESP implements the Unmarshaler interface.
+func (vs *VerbatimString) UnmarshalRESP(br *bufio.Reader) error {
+	body, err := readBulkBody(br, verbatimStringPrefix)
+	if err != nil {
+		return err
+	}
+	if len(body) >= 4 && body[3] == ':' {
+		vs.Format, vs.S = string(body[:3]), string(body[4:])
+	} else {
+		vs.Format, vs.S = "", string(body)
+	}
+	return nil
+}
+
+// Null is a RESP3 type representing the absence of a value, e.g. "_\r\n". In
+// RESP2 this same concept is represented as a null bulk string or null array,
+// both of which Any already unmarshals as a zero value; Null exists so
+// RESP3-aware code can recognize the dedicated null type explicitly.
+type Null struct{}
+
+// MarshalRESP implements the Marshaler interface.
+func (n Null) MarshalRESP(w io.Writer) error {
+	_, err := w.Write([]byte{nullPrefix, '\r', '\n'})
+	return err
+}
+
+// UnmarshalRESP implements the Unmarshaler interface.
+func (n *Null) UnmarshalRESP(br *bufio.Reader) error {
+	_, err := readSimpleBody(br, nullPrefix)
+	return err
+}
+
+// Push is a RESP3 type for an out-of-band message the server can send at any
+// time, outside the normal request/response cycle (e.g. pub/sub messages and
+// client-side-caching invalidations). A Conn which has completed the RESP3
+// handshake will route these to a registered push handler rather than
+// returning them from Decode; see NewConn3 and PushHandler in the radix
+// package.
+type Push struct {
+	// M holds the raw elements of the push message, unmarshaled as
+	// interface{} in the same fashion as Any without a destination pointer.
+	M []interface{}
+}
+
+// MarshalRESP implements the Marshaler interface.
+func (p Push) MarshalRESP(w io.Writer) error {
+	a := Any{I: p.M, MarshalBulkString: true, MarshalNoArrayHeaders: true}
+	if err := (ArrayHeader{N: a.NumElems()}).marshalPrefixed(w, pushPrefix); err != nil {
+		return err
+	}
+	return a.MarshalRESP(w)
+}
+
+// UnmarshalRESP implements the Unmarshaler interface.
+func (p *Push) UnmarshalRESP(br *bufio.Reader) error {
+	n, err := readArrayHeaderBody(br, pushPrefix)
+	if err != nil {
+		return err
+	}
+	p.M = make([]interface{}, n)
+	a := Any{I: &p.M}
+	return a.unmarshalArrayBody(br, n)
+}
+
+// IsPushHeader returns true if the next bytes buffered in br are the header
+// of a RESP3 push message, without consuming them. A RESP2-only connection
+// will never see a '>' header and this will always return false for it.
+func IsPushHeader(br *bufio.Reader) (bool, error) {
+	b, err := br.Peek(1)
+	if err != nil {
+		return false, err
+	}
+	return b[0] == pushPrefix, nil
+}
+
+// IsMapHeader returns true if the next bytes buffered in br are the header
+// of a RESP3 map, without consuming them. A RESP2-only connection will never
+// see a '%' header and this will always return false for it.
+func IsMapHeader(br *bufio.Reader) (bool, error) {
+	b, err := br.Peek(1)
+	if err != nil {
+		return false, err
+	}
+	return b[0] == mapPrefix, nil
+}
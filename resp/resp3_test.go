@@ -0,0 +1,54 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func TestIsPushHeader(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want bool
+	}{
+		{">2\r\n", true},
+		{"*2\r\n", false},
+		{"%1\r\n", false},
+	} {
+		br := bufio.NewReader(bytes.NewBufferString(tt.in))
+		got, err := IsPushHeader(br)
+		if err != nil {
+			t.Fatalf("IsPushHeader(%q): %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("IsPushHeader(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+		// Peeking must not consume the header byte.
+		if b, _ := br.Peek(1); len(b) == 0 || b[0] != tt.in[0] {
+			t.Errorf("IsPushHeader(%q) consumed the header byte", tt.in)
+		}
+	}
+}
+
+func TestIsMapHeader(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want bool
+	}{
+		{"%1\r\n", true},
+		{"*2\r\n", false},
+		{">1\r\n", false},
+	} {
+		br := bufio.NewReader(bytes.NewBufferString(tt.in))
+		got, err := IsMapHeader(br)
+		if err != nil {
+			t.Fatalf("IsMapHeader(%q): %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("IsMapHeader(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+		if b, _ := br.Peek(1); len(b) == 0 || b[0] != tt.in[0] {
+			t.Errorf("IsMapHeader(%q) consumed the header byte", tt.in)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package radix
+
+import (
+	"bufio"
+	"errors"
+
+	"github.com/mediocregopher/radix.v3/resp"
+)
+
+// PushHandler is called for every out-of-band RESP3 push message (pub/sub
+// messages, client-side-caching invalidations, etc...) received on a Conn
+// which has completed the RESP3 handshake. It is called synchronously from
+// within Conn.Decode, so it should not block.
+type PushHandler func(resp.Push)
+
+// NewConn3 performs the RESP3 HELLO handshake on conn (authUser/authPass may
+// be empty if no AUTH is configured) and, if it succeeds, wraps conn so h
+// receives any out-of-band push messages Decode encounters from then on. It
+// returns the Conn to use going forward, which callers should use in place
+// of conn.
+//
+// If proto is 0, NewConn3 does nothing and returns conn as-is; this is how a
+// caller opts out of RESP3 and stays on RESP2. This is meant to be called
+// from newConnection as part of connection setup, prior to the Conn being
+// handed back to the caller.
+func NewConn3(conn Conn, proto int, authUser, authPass string, h PushHandler) (Conn, error) {
+	if err := protoHELLO(conn, proto, authUser, authPass); err != nil {
+		return nil, err
+	}
+	if proto == 0 {
+		return conn, nil
+	}
+	cw := &connWrap{Conn: conn}
+	cw.SetPushHandler(h)
+	return cw, nil
+}
+
+// protoHELLO performs the RESP3 HELLO handshake on a newly established Conn,
+// doing nothing (staying on RESP2) if proto is 0. If the server rejects
+// HELLO (e.g. redis < 6 doesn't support it), that error is returned; there
+// is no automatic fallback to RESP2. authUser/authPass may be empty if no
+// AUTH is configured.
+func protoHELLO(conn Conn, proto int, authUser, authPass string) error {
+	if proto == 0 {
+		return nil
+	}
+
+	args := []string{itoa(proto)}
+	if authPass != "" {
+		if authUser == "" {
+			authUser = "default"
+		}
+		args = append(args, "AUTH", authUser, authPass)
+	}
+
+	var resh map[string]interface{}
+	cmd := Cmd(&resh, "HELLO", args...)
+	return cmd.Run(conn)
+}
+
+func itoa(i int) string {
+	if i == 3 {
+		return "3"
+	}
+	return "2"
+}
+
+// SetPushHandler installs h as the destination for any RESP3 push messages
+// Decode encounters on conn, replacing any previously set handler. A nil h
+// discards push messages (the default).
+//
+// Regular command replies continue to be unmarshaled and returned from
+// Decode as before; only frames beginning with the RESP3 '>' push header are
+// diverted to h.
+func (conn *connWrap) SetPushHandler(h PushHandler) {
+	conn.pushHandler = h
+}
+
+// connWrap augments a RESP3-capable Conn's Decode with push-frame routing.
+// It wraps the br passed to resp.Unmarshaler.UnmarshalRESP implementations
+// so that consecutive push frames are peeled off and delivered to
+// pushHandler before the caller's Unmarshaler ever sees them.
+type connWrap struct {
+	Conn
+	pushHandler PushHandler
+}
+
+func (conn *connWrap) Decode(u resp.Unmarshaler) error {
+	br, err := conn.reader()
+	if err != nil {
+		return err
+	}
+	for {
+		isPush, err := resp.IsPushHeader(br)
+		if err != nil {
+			return err
+		}
+		if !isPush {
+			break
+		}
+		var push resp.Push
+		if err := push.UnmarshalRESP(br); err != nil {
+			return err
+		}
+		if conn.pushHandler != nil {
+			conn.pushHandler(push)
+		}
+	}
+	return u.UnmarshalRESP(br)
+}
+
+// reader exposes the *bufio.Reader backing the wrapped Conn so push frames
+// can be peeked without consuming a full Decode call. Conn implementations
+// which support RESP3 push messages must implement this; reader returns an
+// error rather than panicking if the wrapped Conn doesn't.
+func (conn *connWrap) reader() (*bufio.Reader, error) {
+	type bufioReader interface {
+		BufioReader() *bufio.Reader
+	}
+	br, ok := conn.Conn.(bufioReader)
+	if !ok {
+		return nil, errors.New("radix: Conn does not support RESP3 push messages (missing BufioReader method)")
+	}
+	return br.BufioReader(), nil
+}
@@ -0,0 +1,189 @@
+package radix
+
+import (
+	"bufio"
+	"strconv"
+
+	"github.com/mediocregopher/radix.v3/resp"
+)
+
+// ScanOpts are the options used for creating a new Scanner. Cursor is not
+// included in these options because the Scanner implementation handles
+// cursor management itself.
+type ScanOpts struct {
+	// Command is the scan command to use, one of "SCAN", "HSCAN", "SSCAN", or
+	// "ZSCAN".
+	Command string
+
+	// Key is the key to scan, for the HSCAN/SSCAN/ZSCAN commands. Not used
+	// for SCAN.
+	Key string
+
+	// Pattern optionally corresponds to the MATCH option.
+	Pattern string
+
+	// Count optionally corresponds to the COUNT option. It is 0 by default,
+	// in which case COUNT is not sent and the default of 10 is used.
+	Count int
+
+	// Type optionally corresponds to the TYPE option of SCAN, restricting
+	// the keys returned to those of the given type. Only used for SCAN.
+	Type string
+}
+
+// Scanner is used to iterate through the results of a SCAN, HSCAN, SSCAN, or
+// ZSCAN call, transparently following the returned cursor until it's
+// exhausted.
+//
+//	var key string
+//	s := radix.NewScanner(client, radix.ScanOpts{Command: "SCAN"})
+//	for s.Next(&key) {
+//		fmt.Println(key)
+//	}
+//	if err := s.Close(); err != nil {
+//		panic(err)
+//	}
+type Scanner interface {
+	// Next fills the given string pointer with the next result from the
+	// scan, and returns true. If the scan has been exhausted or an error
+	// occurred it returns false, and that error (if any) is returned by
+	// Close.
+	Next(*string) bool
+
+	// Close releases any resources held by the Scanner and returns an error
+	// if the scan failed to complete at any point.
+	Close() error
+}
+
+// NewScanner creates a new Scanner which will scan over client using the
+// given options. Nothing is sent to client until the first call to Next.
+//
+// A SCAN cursor is only valid against the single redis instance which
+// returned it, so when client is a *Cluster each master node must be scanned
+// separately; use NewClusterScanner in that case instead.
+func NewScanner(client Client, o ScanOpts) Scanner {
+	return &scanner{
+		client: client,
+		o:      o,
+		cursor: "0",
+	}
+}
+
+type scanner struct {
+	client Client
+	o      ScanOpts
+
+	cursor string
+	res    []string
+	err    error
+	done   bool
+}
+
+func (s *scanner) args() []string {
+	args := make([]string, 0, 6)
+	if s.o.Key != "" && s.o.Command != "SCAN" {
+		args = append(args, s.o.Key)
+	}
+	args = append(args, s.cursor)
+	if s.o.Pattern != "" {
+		args = append(args, "MATCH", s.o.Pattern)
+	}
+	if s.o.Count > 0 {
+		args = append(args, "COUNT", strconv.Itoa(s.o.Count))
+	}
+	if s.o.Type != "" && s.o.Command == "SCAN" {
+		args = append(args, "TYPE", s.o.Type)
+	}
+	return args
+}
+
+// scanResult captures the two-element [cursor, [elem...]] shape a SCAN-family
+// command replies with, which Any can't be pointed at directly since it
+// isn't a flat array.
+type scanResult struct {
+	cursor string
+	elems  []string
+}
+
+func (sr *scanResult) UnmarshalRESP(br *bufio.Reader) error {
+	var arr [2]resp.RawMessage
+	if err := (resp.Any{I: &arr}).UnmarshalRESP(br); err != nil {
+		return err
+	}
+	if err := arr[0].UnmarshalInto(resp.Any{I: &sr.cursor}); err != nil {
+		return err
+	}
+	return arr[1].UnmarshalInto(resp.Any{I: &sr.elems})
+}
+
+func (s *scanner) fillBatch() bool {
+	var sr scanResult
+	cmd := Cmd(&sr, s.o.Command, s.args()...)
+	if s.err = s.client.Do(cmd); s.err != nil {
+		return false
+	}
+	s.cursor = sr.cursor
+	s.res = sr.elems
+	if s.cursor == "0" {
+		s.done = true
+	}
+	return true
+}
+
+func (s *scanner) Next(out *string) bool {
+	for len(s.res) == 0 {
+		if s.done || s.err != nil {
+			return false
+		}
+		if !s.fillBatch() {
+			return false
+		}
+	}
+	*out = s.res[0]
+	s.res = s.res[1:]
+	return true
+}
+
+func (s *scanner) Close() error {
+	return s.err
+}
+
+// NewClusterScanner is like NewScanner, but scans every master node of
+// cluster in turn, so that the full keyspace is covered rather than just
+// whichever node the first SCAN happens to land on.
+func NewClusterScanner(cluster *Cluster, o ScanOpts) Scanner {
+	clients := cluster.masterClients()
+	return &clusterScanner{clients: clients, o: o}
+}
+
+type clusterScanner struct {
+	clients []Client
+	o       ScanOpts
+	i       int
+	cur     Scanner
+	lastErr error
+}
+
+func (cs *clusterScanner) Next(out *string) bool {
+	for {
+		if cs.cur == nil {
+			if cs.i >= len(cs.clients) {
+				return false
+			}
+			cs.cur = NewScanner(cs.clients[cs.i], cs.o)
+			cs.i++
+		}
+		if cs.cur.Next(out) {
+			return true
+		}
+		if err := cs.cur.Close(); err != nil {
+			cs.lastErr = err
+			return false
+		}
+		cs.cur = nil
+	}
+}
+
+func (cs *clusterScanner) Close() error {
+	return cs.lastErr
+}
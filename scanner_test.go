@@ -0,0 +1,76 @@
+package radix
+
+import "testing"
+
+// fakeScanClient replays a canned sequence of SCAN-family batches, one per
+// Do call, for testing Scanner without a real redis.
+type fakeScanClient struct {
+	batches [][2]interface{} // cursor, elems
+	i       int
+}
+
+func (f *fakeScanClient) Do(a Action) error {
+	cmd := a.(CmdAction)
+	sr := cmd.(*cmdAction).rcv.(*scanResult)
+	b := f.batches[f.i]
+	f.i++
+	sr.cursor = b[0].(string)
+	sr.elems = b[1].([]string)
+	return nil
+}
+
+func (f *fakeScanClient) Close() error { return nil }
+
+func TestScannerIteratesAllBatches(t *testing.T) {
+	fc := &fakeScanClient{batches: [][2]interface{}{
+		{"1", []string{"a", "b"}},
+		{"2", []string{"c"}},
+		{"0", []string{"d", "e"}},
+	}}
+	s := NewScanner(fc, ScanOpts{Command: "SCAN"})
+
+	var got []string
+	var k string
+	for s.Next(&k) {
+		got = append(got, k)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	want := []string{"a", "b", "c", "d", "e"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestScannerArgsIncludeKeyPatternAndCount(t *testing.T) {
+	s := &scanner{
+		o:      ScanOpts{Command: "HSCAN", Key: "myhash", Pattern: "foo*", Count: 50},
+		cursor: "0",
+	}
+	args := s.args()
+	want := []string{"myhash", "0", "MATCH", "foo*", "COUNT", "50"}
+	if len(args) != len(want) {
+		t.Fatalf("args() = %v, want %v", args, want)
+	}
+	for i := range want {
+		if args[i] != want[i] {
+			t.Fatalf("args() = %v, want %v", args, want)
+		}
+	}
+}
+
+func TestScannerTypeOnlyAppliesToScan(t *testing.T) {
+	s := &scanner{o: ScanOpts{Command: "HSCAN", Type: "string"}, cursor: "0"}
+	for _, a := range s.args() {
+		if a == "TYPE" {
+			t.Fatalf("TYPE should not be sent for HSCAN, got args %v", s.args())
+		}
+	}
+}
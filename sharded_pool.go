@@ -0,0 +1,84 @@
+package radix
+
+// ShardedPool is a Client which distributes keys across a fixed, operator-
+// managed fleet of independent (i.e. non-cluster) redis instances, using a
+// Sharder to decide which instance a given key belongs to. This is distinct
+// from Cluster, which relies on redis' own CLUSTER slot assignment and
+// MOVED/ASK redirection.
+//
+// ShardedPool does not rebalance data between instances itself; when the set
+// of nodes changes the Sharder will remap some keys to different instances,
+// and it's up to the operator (or a separate migration step) to move that
+// data accordingly.
+type ShardedPool struct {
+	sharder Sharder
+	pools   map[string]Client
+}
+
+// NewShardedPool initializes a ShardedPool from a set of already-connected
+// per-node Clients (e.g. Pools), keyed by the same node ids given to
+// sharder.SetNodes. If sharder is nil, a ConsistentHash with 100 virtual
+// nodes per instance is used, matching ShardedPool's original behavior.
+func NewShardedPool(pools map[string]Client, sharder Sharder) *ShardedPool {
+	if sharder == nil {
+		sharder = NewConsistentHash(100)
+	}
+
+	nodeIDs := make([]string, 0, len(pools))
+	for id := range pools {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sharder.SetNodes(nodeIDs...)
+
+	return &ShardedPool{sharder: sharder, pools: pools}
+}
+
+// SetNodes updates the set of node ids the Sharder picks from, for use after
+// Pools have been added to or removed from sp.pools. It does not add or
+// remove entries from sp.pools itself.
+func (sp *ShardedPool) SetNodes(nodeIDs ...string) {
+	sp.sharder.SetNodes(nodeIDs...)
+}
+
+// poolFor returns the per-node Client that key is routed to.
+func (sp *ShardedPool) poolFor(key string) Client {
+	return sp.pools[sp.sharder.Pick(key)]
+}
+
+// Do implements the Client interface by routing a to the node its first key
+// (if any) is sharded to. Actions without keys (a.Keys() returning empty) are
+// an error, since there'd be no way to pick a node for them.
+func (sp *ShardedPool) Do(a Action) error {
+	keys := a.Keys()
+	if len(keys) == 0 {
+		return errClientNoKeys
+	}
+	return sp.poolFor(keys[0]).Do(a)
+}
+
+// WithConn is like the top-level WithConn, but runs fn against the Conn of
+// whichever node's Client key is sharded to, rather than an arbitrary single
+// Client's Conn.
+func (sp *ShardedPool) WithConn(key string, fn func(Conn) error) error {
+	return sp.poolFor(key).Do(WithConn(key, fn))
+}
+
+// Close closes every underlying per-node Client, returning the first error
+// encountered (if any), after attempting to close them all.
+func (sp *ShardedPool) Close() error {
+	var firstErr error
+	for _, p := range sp.pools {
+		if closer, ok := p.(interface{ Close() error }); ok {
+			if err := closer.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+var errClientNoKeys = shardedPoolError("radix: ShardedPool requires an Action with at least one key")
+
+type shardedPoolError string
+
+func (e shardedPoolError) Error() string { return string(e) }
@@ -0,0 +1,105 @@
+package radix
+
+import (
+	"testing"
+
+	"github.com/mediocregopher/radix.v3/resp"
+)
+
+// noopConn is a Conn that does nothing, for Actions whose Run needs some
+// Conn to execute against but which don't actually need it to do anything.
+type noopConn struct{}
+
+func (noopConn) Encode(resp.Marshaler) error   { return nil }
+func (noopConn) Decode(resp.Unmarshaler) error { return nil }
+func (noopConn) Close() error                  { return nil }
+
+// fakeShardClient is a minimal Client which records the Actions run against
+// it (actually running them against a noopConn), for asserting ShardedPool
+// routed to the right node.
+type fakeShardClient struct {
+	ran []Action
+}
+
+func (f *fakeShardClient) Do(a Action) error {
+	f.ran = append(f.ran, a)
+	return a.Run(noopConn{})
+}
+
+func (f *fakeShardClient) Close() error { return nil }
+
+func TestShardedPoolRoutesByKey(t *testing.T) {
+	a, b := &fakeShardClient{}, &fakeShardClient{}
+	sp := NewShardedPool(map[string]Client{"a": a, "b": b}, nil)
+
+	// find one key that picks "a" and one that picks "b"; with only two
+	// nodes every key picks exactly one of them.
+	var keyForA, keyForB string
+	for i := 0; i < 1000 && (keyForA == "" || keyForB == ""); i++ {
+		k := "key" + string(rune(i))
+		switch sp.poolFor(k) {
+		case a:
+			keyForA = k
+		case b:
+			keyForB = k
+		}
+	}
+	if keyForA == "" || keyForB == "" {
+		t.Fatal("couldn't find keys routing to both nodes")
+	}
+
+	if err := sp.Do(Cmd(nil, "GET", keyForA)); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if len(a.ran) != 1 || len(b.ran) != 0 {
+		t.Fatalf("expected the command to run against node a only, got a=%d b=%d", len(a.ran), len(b.ran))
+	}
+
+	if err := sp.Do(Cmd(nil, "GET", keyForB)); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if len(a.ran) != 1 || len(b.ran) != 1 {
+		t.Fatalf("expected the command to also run against node b, got a=%d b=%d", len(a.ran), len(b.ran))
+	}
+}
+
+func TestShardedPoolDoNoKeysErrors(t *testing.T) {
+	a := &fakeShardClient{}
+	sp := NewShardedPool(map[string]Client{"a": a}, nil)
+
+	if err := sp.Do(Cmd(nil, "PING")); err != errClientNoKeys {
+		t.Fatalf("Do with a keyless Action = %v, want errClientNoKeys", err)
+	}
+}
+
+func TestShardedPoolWithConnRoutesByKey(t *testing.T) {
+	a, b := &fakeShardClient{}, &fakeShardClient{}
+	sp := NewShardedPool(map[string]Client{"a": a, "b": b}, nil)
+
+	var keyForA string
+	for i := 0; i < 1000; i++ {
+		k := "key" + string(rune(i))
+		if sp.poolFor(k) == a {
+			keyForA = k
+			break
+		}
+	}
+	if keyForA == "" {
+		t.Fatal("couldn't find a key routing to node a")
+	}
+
+	called := false
+	err := sp.WithConn(keyForA, func(Conn) error {
+		called = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithConn: %v", err)
+	}
+	if !called {
+		t.Fatal("fn was never called")
+	}
+	if len(a.ran) != 1 {
+		t.Fatalf("expected the WithConn Action to run against node a, got a=%d b=%d", len(a.ran), len(b.ran))
+	}
+}
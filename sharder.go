@@ -0,0 +1,140 @@
+package radix
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Sharder picks which node of a fixed (non-cluster) set of redis instances a
+// given key belongs to. It's used by ShardedPool to distribute keys across
+// an operator-managed fleet of independent redis instances, as opposed to
+// the Cluster type which relies on redis' own CLUSTER slot assignment.
+type Sharder interface {
+	// Pick returns the id (as given to SetNodes) of the node key should be
+	// routed to, or "" if no nodes have been set.
+	Pick(key string) (nodeID string)
+
+	// SetNodes replaces the full set of candidate node ids. It's called
+	// whenever ShardedPool's view of the fleet changes (nodes added or
+	// removed).
+	SetNodes(nodeIDs ...string)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ConsistentHash is a Sharder implementing traditional consistent hashing
+// with virtual nodes: each real node is hashed into many points on a ring,
+// and a key is routed to whichever point on the ring follows it. It's the
+// default Sharder for ShardedPool when none is given explicitly; Rendezvous
+// is the other option, and requires no virtual-node bookkeeping.
+type ConsistentHash struct {
+	vnodes int
+
+	l     sync.RWMutex
+	ring  []uint64
+	byPos map[uint64]string
+}
+
+// NewConsistentHash initializes a ConsistentHash using vnodes virtual nodes
+// per real node; 100 is a reasonable default if unsure.
+func NewConsistentHash(vnodes int) *ConsistentHash {
+	return &ConsistentHash{vnodes: vnodes, byPos: map[uint64]string{}}
+}
+
+// SetNodes implements the Sharder interface.
+func (c *ConsistentHash) SetNodes(nodeIDs ...string) {
+	ring := make([]uint64, 0, len(nodeIDs)*c.vnodes)
+	byPos := make(map[uint64]string, len(nodeIDs)*c.vnodes)
+	for _, id := range nodeIDs {
+		for v := 0; v < c.vnodes; v++ {
+			pos := xxhash.Sum64String(id + "-" + itoaFast(v))
+			ring = append(ring, pos)
+			byPos[pos] = id
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i] < ring[j] })
+
+	c.l.Lock()
+	c.ring, c.byPos = ring, byPos
+	c.l.Unlock()
+}
+
+// Pick implements the Sharder interface.
+func (c *ConsistentHash) Pick(key string) string {
+	h := xxhash.Sum64String(key)
+
+	c.l.RLock()
+	defer c.l.RUnlock()
+	if len(c.ring) == 0 {
+		return ""
+	}
+	i := sort.Search(len(c.ring), func(i int) bool { return c.ring[i] >= h })
+	if i == len(c.ring) {
+		i = 0
+	}
+	return c.byPos[c.ring[i]]
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Rendezvous is a Sharder implementing rendezvous (Highest Random Weight)
+// hashing: for a given key, every candidate node's score is computed as
+// the xxhash of nodeID, a NUL byte, and key, and the node with the highest
+// score wins. Unlike
+// ConsistentHash this requires no virtual-node bookkeeping, and adding or
+// removing a single node only reshuffles the keys which hashed highest for
+// that node, leaving every other key's assignment unchanged.
+type Rendezvous struct {
+	l       sync.RWMutex
+	nodeIDs []string
+}
+
+// NewRendezvous initializes an empty Rendezvous; call SetNodes to populate
+// it with the fleet's node ids before use.
+func NewRendezvous() *Rendezvous {
+	return &Rendezvous{}
+}
+
+// SetNodes implements the Sharder interface.
+func (r *Rendezvous) SetNodes(nodeIDs ...string) {
+	cp := make([]string, len(nodeIDs))
+	copy(cp, nodeIDs)
+
+	r.l.Lock()
+	r.nodeIDs = cp
+	r.l.Unlock()
+}
+
+// Pick implements the Sharder interface.
+func (r *Rendezvous) Pick(key string) string {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	var (
+		best      string
+		bestScore uint64
+	)
+	for i, id := range r.nodeIDs {
+		score := xxhash.Sum64String(id + "\x00" + key)
+		if i == 0 || score > bestScore {
+			best, bestScore = id, score
+		}
+	}
+	return best
+}
+
+func itoaFast(i int) string {
+	if i == 0 {
+		return "0"
+	}
+	var b [20]byte
+	pos := len(b)
+	for i > 0 {
+		pos--
+		b[pos] = byte('0' + i%10)
+		i /= 10
+	}
+	return string(b[pos:])
+}
@@ -0,0 +1,68 @@
+package radix
+
+import "testing"
+
+func TestConsistentHashPickIsStable(t *testing.T) {
+	c := NewConsistentHash(100)
+	c.SetNodes("a", "b", "c")
+
+	first := c.Pick("somekey")
+	for i := 0; i < 10; i++ {
+		if got := c.Pick("somekey"); got != first {
+			t.Fatalf("Pick(%q) = %q, want stable %q", "somekey", got, first)
+		}
+	}
+}
+
+func TestConsistentHashPickEmpty(t *testing.T) {
+	c := NewConsistentHash(100)
+	if got := c.Pick("somekey"); got != "" {
+		t.Errorf("Pick on an empty ConsistentHash = %q, want \"\"", got)
+	}
+}
+
+func TestRendezvousPickIsStable(t *testing.T) {
+	r := NewRendezvous()
+	r.SetNodes("a", "b", "c")
+
+	first := r.Pick("somekey")
+	for i := 0; i < 10; i++ {
+		if got := r.Pick("somekey"); got != first {
+			t.Fatalf("Pick(%q) = %q, want stable %q", "somekey", got, first)
+		}
+	}
+}
+
+func TestRendezvousPickEmpty(t *testing.T) {
+	r := NewRendezvous()
+	if got := r.Pick("somekey"); got != "" {
+		t.Errorf("Pick on an empty Rendezvous = %q, want \"\"", got)
+	}
+}
+
+// TestRendezvousMinimalRemap checks the headline property of rendezvous
+// hashing: removing one node out of N should only remap keys which had
+// picked that node, leaving everyone else's assignment unchanged.
+func TestRendezvousMinimalRemap(t *testing.T) {
+	r := NewRendezvous()
+	nodes := []string{"n0", "n1", "n2", "n3", "n4"}
+	r.SetNodes(nodes...)
+
+	before := map[string]string{}
+	var keys []string
+	for i := 0; i < 200; i++ {
+		k := "key" + string(rune('a'+i%26)) + string(rune('A'+i%13)) + string(rune('0'+i%10))
+		keys = append(keys, k)
+		before[k] = r.Pick(k)
+	}
+
+	removed := nodes[0]
+	r.SetNodes(nodes[1:]...)
+
+	for _, k := range keys {
+		after := r.Pick(k)
+		if before[k] != removed && after != before[k] {
+			t.Fatalf("key %q remapped from %q to %q after removing unrelated node %q", k, before[k], after, removed)
+		}
+	}
+}